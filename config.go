@@ -1,7 +1,11 @@
 package zeus_log
 
 import (
+	"fmt"
 	yaml "gopkg.in/yaml.v3"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,6 +18,10 @@ const (
 type Config []OutputConfig
 
 type OutputConfig struct {
+	// Name is the optional name of the output, by which it's addressed in
+	// Logger.SetLevel and Logger.GetLevel.
+	Name string `yaml:"name"`
+
 	// Writer is the output of log, includes console, file and remote.
 	Writer       string       `yaml:"writer"`
 	WriterConfig WriterConfig `yaml:"writer_config"`
@@ -32,8 +40,126 @@ type OutputConfig struct {
 	// CallerSkip controls the nesting depth of log function.
 	CallerSkip int `yaml:"caller_skip"`
 
+	// StacktraceLevel is the level from which stack traces are attached to
+	// logs, like "error". No stack traces by default.
+	StacktraceLevel string `yaml:"stacktrace_level"`
+	// StacktraceDepth is the max number of frames of stack traces, default as 32.
+	StacktraceDepth int `yaml:"stacktrace_depth"`
+
 	// EnableColor determines if the output is colored. The default value is false.
 	EnableColor bool `yaml:"enable_color"`
+
+	// Sampling limits the logs of the output with the same level and message.
+	// No sampling by default.
+	Sampling SamplingConfig `yaml:"sampling"`
+
+	// Throttle limits the volume of logs of the output. No limit by default.
+	Throttle ThrottleConfig `yaml:"throttle"`
+
+	// Dedup suppresses consecutive duplicate logs of the output. Disabled by
+	// default.
+	Dedup DedupConfig `yaml:"dedup"`
+}
+
+// DedupConfig is the config of suppressing consecutive duplicate logs, which
+// have the same level, logger name, message and fields. Only the first one is
+// written, followed by "last message repeated N times" when a different log
+// is written, when the window has passed since the first suppressed one, or
+// when the output is synced or closed:
+//
+//	dedup:
+//	  window: 30s
+type DedupConfig struct {
+	// Window is the longest time a repeat summary is delayed, like "30s", 0
+	// disables suppressing.
+	Window time.Duration `yaml:"window"`
+}
+
+// Enabled reports whether duplicate logs are suppressed.
+func (c DedupConfig) Enabled() bool {
+	return c.Window > 0
+}
+
+// ThrottleConfig is the budget of logs an output writes per second. When the
+// budget of a second runs low, logs of lower levels are shed first: trace and
+// debug logs only use the first half of the budget, info logs the first three
+// quarters, and warn logs all of it. Error and higher levels are never shed.
+// A summary of the shed logs is written every SummaryInterval if any log is
+// shed, and when the output is synced or closed:
+//
+//	throttle:
+//	  entries_per_second: 1000
+//	  bytes_per_second: 1MB
+//	  summary_interval: 10s
+type ThrottleConfig struct {
+	// EntriesPerSecond is the number of logs per second, 0 for no limit.
+	EntriesPerSecond int `yaml:"entries_per_second"`
+	// BytesPerSecond is the approximate size of logs per second, like 1048576
+	// or "1MB", 0 for no limit.
+	BytesPerSecond Bytes `yaml:"bytes_per_second"`
+	// SummaryInterval is the interval of summaries of shed logs, like "10s",
+	// default as 10 seconds.
+	SummaryInterval time.Duration `yaml:"summary_interval"`
+}
+
+// Enabled reports whether any budget is set.
+func (c ThrottleConfig) Enabled() bool {
+	return c.EntriesPerSecond > 0 || c.BytesPerSecond > 0
+}
+
+// SamplingConfig is the sampling config of an output. In each tick, the first
+// Initial logs with the same level and message are written, then every
+// Thereafter-th one, the others are dropped. Like throttling, error and higher
+// levels are never sampled by default, only by their own policies in Levels:
+//
+//	sampling:
+//	  tick: 1s
+//	  initial: 100
+//	  thereafter: 10
+//	  levels:
+//	    debug: {initial: 10, thereafter: 100}
+//	    error: {initial: 1000, thereafter: 100} # errors are sampled only if set.
+type SamplingConfig struct {
+	// Tick is the sampling interval, like "1s", default as 1 second.
+	Tick time.Duration `yaml:"tick"`
+	// Initial is the number of logs written in each tick before sampling.
+	Initial int `yaml:"initial"`
+	// Thereafter is the interval of logs written after Initial ones, no more
+	// logs are written in the tick if it's 0.
+	Thereafter int `yaml:"thereafter"`
+	// Levels overrides Initial and Thereafter by level names. It's the only
+	// way to sample error and higher levels.
+	Levels map[string]SamplingPolicy `yaml:"levels"`
+}
+
+// SamplingPolicy is the sampling policy of a level.
+type SamplingPolicy struct {
+	Initial    int `yaml:"initial"`
+	Thereafter int `yaml:"thereafter"`
+}
+
+// Enabled reports whether logs are sampled by the policy. Both Initial and
+// Thereafter being 0 disables sampling.
+func (p SamplingPolicy) Enabled() bool {
+	return p.Initial > 0 || p.Thereafter > 0
+}
+
+// Enabled reports whether logs of any level are sampled.
+func (c SamplingConfig) Enabled() bool {
+	if c.Policy().Enabled() {
+		return true
+	}
+	for _, p := range c.Levels {
+		if p.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy returns the sampling policy of levels below error not in Levels.
+func (c SamplingConfig) Policy() SamplingPolicy {
+	return SamplingPolicy{Initial: c.Initial, Thereafter: c.Thereafter}
 }
 
 // WriterConfig is the local file config.
@@ -47,14 +173,14 @@ type WriterConfig struct {
 	WriteMode string `yaml:"write_mode"`
 	// RollType is the log rolling type. Split files by size/time.(default as time)
 	RollType string `yaml:"roll_type"`
-	// MaxAge is the max expire times(day).
-	MaxAge int `yaml:"max_age"`
+	// MaxAge is the max expire times(day), like 7 or "7d".
+	MaxAge Days `yaml:"max_age"`
 	// MaxBackups is the max backup files.
 	MaxBackups int `yaml:"max_backups"`
 	// Compress defines whether log should be compressed.
 	Compress bool `yaml:"compress"`
-	// MaxSize is the max size of log file(MB).
-	MaxSize int `yaml:"max_size"`
+	// MaxSize is the max size of log file(MB), like 100 or "100MB".
+	MaxSize Megabytes `yaml:"max_size"`
 
 	// TimeUnit splits files by time unit, like year/month/hour/minute, default
 	// as day. It takes effect only when split by time.
@@ -82,6 +208,23 @@ type FormatConfig struct {
 	MessageKey string `yaml:"message_key"`
 	// StacktraceKey is the stack trace key of log output, default as "stacktrace".
 	StacktraceKey string `yaml:"stacktrace_key"`
+	// CallerFormat is how callers are printed: "short", the default, like
+	// "pkg/file.go:12", "full" file paths, "module-relative" paths of the
+	// files in the main module, like "internal/pkg/file.go:12", or "none".
+	CallerFormat string `yaml:"caller_format"`
+	// FunctionFormat is how function names are printed: "full" names like
+	// "github.com/org/app/pkg.(*T).Method", "package" names like
+	// "pkg.(*T).Method", or "method" names like "Method". Function names are
+	// printed under FunctionKey, default as "F" if FunctionFormat is set.
+	FunctionFormat string `yaml:"function_format"`
+	// StacktraceFormat is how JSON output emits stack traces, either "string",
+	// the default, or "array" of frames. Console output always prints them on
+	// multiple lines.
+	StacktraceFormat string `yaml:"stacktrace_format"`
+
+	// Options is the config of the formatter. It's defined by the formatter
+	// registered by RegisterFormatter, and decoded by it.
+	Options yaml.Node `yaml:"options"`
 }
 
 // WriteMode is the log write mode, one of 1, 2, 3.
@@ -226,3 +369,148 @@ func (t TimeUnit) RotationGap() time.Duration {
 		return time.Hour * 24
 	}
 }
+
+// Megabytes is a size in megabytes. In YAML it's either a plain number of
+// megabytes, or a string with a unit like "512KB", "100MB" or "2GB". Units are
+// powers of 1024, sizes which are not a multiple of a megabyte are rounded up.
+type Megabytes int
+
+// sizeUnits maps the size units to their number of bytes.
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+}
+
+// ParseMegabytes parses a size like "100MB", a plain number is taken as megabytes.
+func ParseMegabytes(s string) (Megabytes, error) {
+	v, err := parseSize(s, 1<<20)
+	if err != nil {
+		return 0, err
+	}
+	return Megabytes(math.Ceil(v / (1 << 20))), nil
+}
+
+// parseSize parses a size like "100MB" into bytes, a plain number is taken as
+// the number of plain units.
+func parseSize(s string, plain float64) (float64, error) {
+	num, unit := splitNumberUnit(s)
+	if num == "" && unit == "" {
+		return 0, fmt.Errorf("invalid size %q: empty, want a non-negative number with an optional unit", s)
+	}
+	if num == "" {
+		return 0, fmt.Errorf("invalid size %q: want a non-negative number with an optional unit", s)
+	}
+	scale, ok := sizeUnits[strings.ToUpper(unit)]
+	if unit == "" {
+		scale, ok = plain, true
+	}
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q, allowed: B, KB, MB, GB, TB", s, unit)
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q: want a non-negative number with an optional unit", s)
+	}
+	return v * scale, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *Megabytes) UnmarshalYAML(node *yaml.Node) error {
+	v, err := ParseMegabytes(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*m = v
+	return nil
+}
+
+// Bytes is a size in bytes. In YAML it's either a plain number of bytes, or a
+// string with a unit like "512KB" or "1MB". Units are powers of 1024.
+type Bytes int64
+
+// ParseBytes parses a size like "512KB", a plain number is taken as bytes.
+func ParseBytes(s string) (Bytes, error) {
+	v, err := parseSize(s, 1)
+	if err != nil {
+		return 0, err
+	}
+	return Bytes(math.Ceil(v)), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (b *Bytes) UnmarshalYAML(node *yaml.Node) error {
+	v, err := ParseBytes(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*b = v
+	return nil
+}
+
+// Days is a duration in days. In YAML it's either a plain number of days, or a
+// string with a unit like "36h", "7d" or "2w". Durations which are not a
+// multiple of a day are rounded up.
+type Days int
+
+// dayUnits maps the duration units to their number of hours.
+var dayUnits = map[string]float64{
+	"":  24, // Plain numbers are days.
+	"h": 1,
+	"d": 24,
+	"w": 24 * 7,
+}
+
+// ParseDays parses a duration like "7d", a plain number is taken as days.
+func ParseDays(s string) (Days, error) {
+	num, unit := splitNumberUnit(s)
+	if num == "" && unit == "" {
+		return 0, fmt.Errorf("invalid duration %q: empty, want a non-negative number with an optional unit", s)
+	}
+	if num == "" {
+		return 0, fmt.Errorf("invalid duration %q: want a non-negative number with an optional unit", s)
+	}
+	scale, ok := dayUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("invalid duration %q: unknown unit %q, allowed: h, d, w", s, unit)
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid duration %q: want a non-negative number with an optional unit", s)
+	}
+	return Days(math.Ceil(v * scale / 24)), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Days) UnmarshalYAML(node *yaml.Node) error {
+	v, err := ParseDays(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = v
+	return nil
+}
+
+// splitNumberUnit splits s like "100MB" into the leading decimal number "100"
+// and the unit "MB". Signs and exponents are not part of the number, so "-1"
+// has no number and "1e3" has the unit "e3".
+func splitNumberUnit(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
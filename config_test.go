@@ -0,0 +1,124 @@
+package zeus_log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitNumberUnit(t *testing.T) {
+	tests := []struct {
+		s, num, unit string
+	}{
+		{s: "100MB", num: "100", unit: "MB"},
+		{s: " 1.5 GB ", num: "1.5", unit: "GB"},
+		{s: "36h", num: "36", unit: "h"},
+		{s: "7", num: "7", unit: ""},
+		{s: "MB", num: "", unit: "MB"},
+		{s: "-1", num: "", unit: "-1"},
+		{s: "1e3", num: "1", unit: "e3"},
+		{s: "", num: "", unit: ""},
+	}
+	for _, tt := range tests {
+		if num, unit := splitNumberUnit(tt.s); num != tt.num || unit != tt.unit {
+			t.Errorf("splitNumberUnit(%q) = %q, %q, want %q, %q", tt.s, num, unit, tt.num, tt.unit)
+		}
+	}
+}
+
+func TestParseMegabytes(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Megabytes
+		err  string
+	}{
+		{s: "100", want: 100},
+		{s: "100MB", want: 100},
+		{s: "1.5GB", want: 1536},
+		{s: "2gib", want: 2048},
+		{s: "1TB", want: 1 << 20},
+		{s: "512KB", want: 1},
+		{s: "1B", want: 1},
+		{s: "0", want: 0},
+		{s: "MB", err: `invalid size "MB": want a non-negative number`},
+		{s: "-1", err: `invalid size "-1": want a non-negative number`},
+		{s: "1e3", err: `invalid size "1e3": unknown unit "e3"`},
+		{s: "", err: `invalid size "": empty`},
+		{s: "1.2.3MB", err: `invalid size "1.2.3MB": want a non-negative number`},
+		{s: "10PB", err: `invalid size "10PB": unknown unit "PB", allowed: B, KB, MB, GB, TB`},
+	}
+	for _, tt := range tests {
+		got, err := ParseMegabytes(tt.s)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseMegabytes(%q) = %v, %v, want error %q", tt.s, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMegabytes(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Bytes
+		err  string
+	}{
+		{s: "100", want: 100},
+		{s: "1.5KB", want: 1536},
+		{s: "1MB", want: 1 << 20},
+		{s: "1.5GB", want: 3 << 29},
+		{s: "0.5", want: 1},
+		{s: "MB", err: `invalid size "MB": want a non-negative number`},
+		{s: "-1", err: `invalid size "-1": want a non-negative number`},
+		{s: "1e3", err: `invalid size "1e3": unknown unit "e3"`},
+		{s: "", err: `invalid size "": empty`},
+	}
+	for _, tt := range tests {
+		got, err := ParseBytes(tt.s)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseBytes(%q) = %v, %v, want error %q", tt.s, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBytes(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Days
+		err  string
+	}{
+		{s: "7", want: 7},
+		{s: "7d", want: 7},
+		{s: "2w", want: 14},
+		{s: "36h", want: 2},
+		{s: "24H", want: 1},
+		{s: "1.5", want: 2},
+		{s: "0", want: 0},
+		{s: "h", err: `invalid duration "h": want a non-negative number`},
+		{s: "-1", err: `invalid duration "-1": want a non-negative number`},
+		{s: "1e3", err: `invalid duration "1e3": unknown unit "e3", allowed: h, d, w`},
+		{s: "", err: `invalid duration "": empty`},
+		{s: "1m", err: `invalid duration "1m": unknown unit "m"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseDays(tt.s)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseDays(%q) = %v, %v, want error %q", tt.s, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDays(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
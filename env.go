@@ -0,0 +1,205 @@
+package zeus_log
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/oyogames2023/zeus-log/internal/env"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EnvOverride records a config field overridden by an environment variable.
+type EnvOverride struct {
+	// Logger is the name of the logger.
+	Logger string
+	// Var is the name of the environment variable, like "ZEUS_LOG_LEVEL_ACCESS".
+	Var string
+	// Field is the path of the overridden field, like "output[0].level".
+	Field string
+	// Old is the value in the config.
+	Old string
+	// New is the value of the environment variable.
+	New string
+}
+
+// String returns the override in a readable form.
+func (o EnvOverride) String() string {
+	return fmt.Sprintf("%s: %s=%s overrides %s: %q -> %q", o.Logger, o.Var, o.New, o.Field, o.Old, o.New)
+}
+
+var (
+	envOverridesMu sync.Mutex
+	// envOverrides is the overrides applied to each logger at last setup or reload.
+	envOverrides = make(map[string][]EnvOverride)
+)
+
+// ApplyEnvOverrides returns a copy of cfg of the logger `name` with the config
+// overrides from environment variables applied. The variables are:
+//
+//   - ZEUS_LOG_LEVEL sets the level of all outputs, like "warn".
+//   - ZEUS_LOG_FORMAT sets the formatter of all outputs, like "json".
+//   - ZEUS_LOG_PATH sets the log path of file outputs, like "/data/logs".
+//   - ZEUS_LOG_COLOR turns the color of console outputs on or off, one of the
+//     values accepted by strconv.ParseBool, like "0" or "1".
+//
+// Each variable also has a per logger form made by appending the logger name,
+// upper-cased with non-alphanumeric characters replaced by '_', like
+// ZEUS_LOG_LEVEL_ACCESS for the logger "access". The precedence is: the per
+// logger variable, then the global variable, then the config. Empty variables
+// are ignored. Invalid values, like unknown levels or formatters, are reported
+// as *ConfigError joined by multierror, and not applied.
+//
+// The overrides are not recorded for AppliedEnvOverrides until the logger is
+// built from the returned config, see RecordEnvOverrides. SetupFromBytes,
+// ReloadFromBytes and the default logger apply and record them automatically.
+func ApplyEnvOverrides(name string, cfg Config) (Config, []EnvOverride, error) {
+	out := make(Config, len(cfg))
+	copy(out, cfg)
+	patches, errs := envPatches(name, cfg)
+	overrides := make([]EnvOverride, 0, len(patches))
+	for _, p := range patches {
+		c := &out[p.index]
+		switch p.field {
+		case "level":
+			c.Level = p.New
+		case "formatter":
+			c.Formatter = p.New
+		case "writer_config.log_path":
+			c.WriterConfig.LogPath = p.New
+		case "enable_color":
+			c.EnableColor = p.New == "true"
+		}
+		overrides = append(overrides, p.EnvOverride)
+	}
+	return out, overrides, errs
+}
+
+// RecordEnvOverrides records the overrides which took effect at the setup or
+// reload of the logger `name`, for AppliedEnvOverrides. Log backends call it
+// once the logger built from the config returned by ApplyEnvOverrides is in use.
+func RecordEnvOverrides(name string, overrides []EnvOverride) {
+	envOverridesMu.Lock()
+	defer envOverridesMu.Unlock()
+	envOverrides[name] = overrides
+}
+
+// envPatch is an override of a field of an output config.
+type envPatch struct {
+	EnvOverride
+	// index is the index of the output.
+	index int
+	// field is the YAML path of the field in the output, like "level".
+	field string
+}
+
+// envPatches returns the valid overrides from environment variables of cfg of
+// the logger `name`. New values are in their YAML forms.
+func envPatches(name string, cfg Config) ([]envPatch, error) {
+	var (
+		patches []envPatch
+		errs    error
+	)
+	patch := func(v string, i int, field, old, new string) {
+		patches = append(patches, envPatch{
+			EnvOverride: EnvOverride{
+				Logger: name,
+				Var:    v,
+				Field:  fmt.Sprintf("output[%d].%s", i, field),
+				Old:    old,
+				New:    new,
+			},
+			index: i,
+			field: field,
+		})
+	}
+
+	if v, value := lookupLogEnv(env.LogLevel, name); v != "" {
+		if _, err := ParseLevel(value); err != nil {
+			errs = multierror.Append(errs, envError(v, "level", value, "unknown level", levelNames()...))
+		} else {
+			for i := range cfg {
+				patch(v, i, "level", cfg[i].Level, value)
+			}
+		}
+	}
+	if v, value := lookupLogEnv(env.LogFormat, name); v != "" {
+		if GetFormatter(value) == nil {
+			errs = multierror.Append(errs, envError(v, "formatter", value, "not registered", formatterNames()...))
+		} else {
+			for i := range cfg {
+				patch(v, i, "formatter", cfg[i].Formatter, value)
+			}
+		}
+	}
+	if v, value := lookupLogEnv(env.LogPath, name); v != "" {
+		for i := range cfg {
+			if cfg[i].Writer == OutputFile {
+				patch(v, i, "writer_config.log_path", cfg[i].WriterConfig.LogPath, value)
+			}
+		}
+	}
+	if v, value := lookupLogEnv(env.LogColor, name); v != "" {
+		if color, err := strconv.ParseBool(value); err != nil {
+			errs = multierror.Append(errs, envError(v, "enable_color", value, "invalid bool"))
+		} else {
+			for i := range cfg {
+				if cfg[i].Writer == OutputConsole {
+					patch(v, i, "enable_color", strconv.FormatBool(cfg[i].EnableColor), strconv.FormatBool(color))
+				}
+			}
+		}
+	}
+	return patches, errs
+}
+
+// envError reports the invalid value of the variable v overriding field as
+// *ConfigError, whose Index is -1 since the variable applies to every output.
+func envError(v, field, value, reason string, allowed ...string) error {
+	return &ConfigError{Index: -1, Field: field, Value: value, Reason: reason + " in env " + v, Allowed: allowed}
+}
+
+// AppliedEnvOverrides returns the overrides from environment variables which
+// took effect at the last setup or reload of each logger, ordered by logger name.
+func AppliedEnvOverrides() []EnvOverride {
+	envOverridesMu.Lock()
+	defer envOverridesMu.Unlock()
+	names := make([]string, 0, len(envOverrides))
+	for name := range envOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var all []EnvOverride
+	for _, name := range names {
+		all = append(all, envOverrides[name]...)
+	}
+	return all
+}
+
+// lookupLogEnv returns the name and value of the per logger variable if set,
+// or else of the global variable. The name is empty if neither is set.
+func lookupLogEnv(key, logger string) (string, string) {
+	if v := key + "_" + envLoggerName(logger); os.Getenv(v) != "" {
+		return v, os.Getenv(v)
+	}
+	if value := os.Getenv(key); value != "" {
+		return key, value
+	}
+	return "", ""
+}
+
+// envLoggerName converts a logger name to the form used in variable names.
+func envLoggerName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
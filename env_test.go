@@ -0,0 +1,155 @@
+package zeus_log
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oyogames2023/zeus-log/internal/env"
+)
+
+// clearLogEnv unsets the global ZEUS_LOG_* variables until the test ends.
+func clearLogEnv(t *testing.T) {
+	for _, key := range []string{env.LogLevel, env.LogFormat, env.LogPath, env.LogColor} {
+		t.Setenv(key, "")
+	}
+}
+
+// envTestConfig is a config with a console and a file output.
+func envTestConfig() Config {
+	return Config{
+		{Writer: OutputConsole, Level: "info", EnableColor: true},
+		{Writer: OutputFile, Level: "debug", Formatter: FormatterJSON,
+			WriterConfig: WriterConfig{LogPath: "/var/log", FileName: "app.log"}},
+	}
+}
+
+func TestApplyEnvOverridesLevel(t *testing.T) {
+	clearLogEnv(t)
+	t.Setenv("ZEUS_LOG_LEVEL", "warn")
+	t.Setenv("ZEUS_LOG_LEVEL_ACCESS_LOG", "error")
+
+	cfg := envTestConfig()
+	tests := []struct {
+		logger, v, want string
+	}{
+		{logger: "app", v: "ZEUS_LOG_LEVEL", want: "warn"},
+		// The per logger variable takes precedence over the global one.
+		{logger: "access.log", v: "ZEUS_LOG_LEVEL_ACCESS_LOG", want: "error"},
+		{logger: "Access-Log", v: "ZEUS_LOG_LEVEL_ACCESS_LOG", want: "error"},
+	}
+	for _, tt := range tests {
+		out, overrides, err := ApplyEnvOverrides(tt.logger, cfg)
+		if err != nil {
+			t.Fatalf("ApplyEnvOverrides(%s): %v", tt.logger, err)
+		}
+		if out[0].Level != tt.want || out[1].Level != tt.want {
+			t.Errorf("%s levels = %q, %q, want %q", tt.logger, out[0].Level, out[1].Level, tt.want)
+		}
+		want := []EnvOverride{
+			{Logger: tt.logger, Var: tt.v, Field: "output[0].level", Old: "info", New: tt.want},
+			{Logger: tt.logger, Var: tt.v, Field: "output[1].level", Old: "debug", New: tt.want},
+		}
+		if !reflect.DeepEqual(overrides, want) {
+			t.Errorf("%s overrides = %v, want %v", tt.logger, overrides, want)
+		}
+	}
+	if !reflect.DeepEqual(cfg, envTestConfig()) {
+		t.Errorf("config modified to %+v", cfg)
+	}
+}
+
+func TestApplyEnvOverridesPerWriter(t *testing.T) {
+	clearLogEnv(t)
+	t.Setenv("ZEUS_LOG_PATH", "/data/logs")
+	t.Setenv("ZEUS_LOG_COLOR_APP", "0")
+	t.Setenv("ZEUS_LOG_FORMAT", FormatterJSON)
+	// The built-in formatters are registered by the log backend.
+	if GetFormatter(FormatterJSON) == nil {
+		RegisterFormatter(FormatterJSON, stubFormatter{})
+		t.Cleanup(func() { UnregisterFormatter(FormatterJSON) })
+	}
+
+	out, overrides, err := ApplyEnvOverrides("app", envTestConfig())
+	if err != nil {
+		t.Fatalf("ApplyEnvOverrides: %v", err)
+	}
+	// The path is set on file outputs only, the color on console outputs only.
+	if out[0].WriterConfig.LogPath != "" || out[1].WriterConfig.LogPath != "/data/logs" {
+		t.Errorf("log paths = %q, %q, want only the file one set", out[0].WriterConfig.LogPath, out[1].WriterConfig.LogPath)
+	}
+	if out[0].EnableColor || out[1].EnableColor {
+		t.Error("color not turned off")
+	}
+	if out[0].Formatter != FormatterJSON || out[1].Formatter != FormatterJSON {
+		t.Errorf("formatters = %q, %q, want json", out[0].Formatter, out[1].Formatter)
+	}
+	var fields []string
+	for _, o := range overrides {
+		fields = append(fields, o.Var+" "+o.Field)
+	}
+	want := []string{
+		"ZEUS_LOG_FORMAT output[0].formatter",
+		"ZEUS_LOG_FORMAT output[1].formatter",
+		"ZEUS_LOG_PATH output[1].writer_config.log_path",
+		"ZEUS_LOG_COLOR_APP output[0].enable_color",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("overrides = %q, want %q", fields, want)
+	}
+	if got := overrides[3].String(); got != `app: ZEUS_LOG_COLOR_APP=false overrides output[0].enable_color: "true" -> "false"` {
+		t.Errorf("override string = %s", got)
+	}
+}
+
+func TestApplyEnvOverridesInvalid(t *testing.T) {
+	clearLogEnv(t)
+	t.Setenv("ZEUS_LOG_LEVEL_APP", "verbose")
+	t.Setenv("ZEUS_LOG_FORMAT", "xml")
+	t.Setenv("ZEUS_LOG_COLOR", "maybe")
+	t.Setenv("ZEUS_LOG_PATH", "/data/logs")
+
+	out, overrides, err := ApplyEnvOverrides("app", envTestConfig())
+	tests := []struct {
+		field, value, reason string
+	}{
+		{field: "level", value: "verbose", reason: "unknown level in env ZEUS_LOG_LEVEL_APP"},
+		{field: "formatter", value: "xml", reason: "not registered in env ZEUS_LOG_FORMAT"},
+		{field: "enable_color", value: "maybe", reason: "invalid bool in env ZEUS_LOG_COLOR"},
+	}
+	errs := unwrapAll(err)
+	if len(errs) != len(tests) {
+		t.Fatalf("err = %v, want %d errors", err, len(tests))
+	}
+	for i, tt := range tests {
+		var ce *ConfigError
+		if !errors.As(errs[i], &ce) {
+			t.Errorf("error %d = %v, want *ConfigError", i, errs[i])
+			continue
+		}
+		if ce.Index != -1 || ce.Field != tt.field || ce.Value != tt.value || ce.Reason != tt.reason {
+			t.Errorf("error %d = %+v, want %s %q: %s", i, ce, tt.field, tt.value, tt.reason)
+		}
+	}
+	if want := `log: invalid config: level: "verbose": unknown level in env ZEUS_LOG_LEVEL_APP, allowed: off, trace`; !strings.HasPrefix(errs[0].Error(), want) {
+		t.Errorf("error = %q, want prefix %q", errs[0], want)
+	}
+
+	// Invalid values are not applied, the valid ones are.
+	if out[0].Level != "info" || out[1].Formatter != FormatterJSON || !out[0].EnableColor {
+		t.Errorf("config = %+v, want invalid values not applied", out)
+	}
+	if len(overrides) != 1 || out[1].WriterConfig.LogPath != "/data/logs" {
+		t.Errorf("overrides = %v, want only the path applied", overrides)
+	}
+}
+
+func TestApplyEnvOverridesEmptyIgnored(t *testing.T) {
+	clearLogEnv(t)
+	t.Setenv("ZEUS_LOG_LEVEL_APP", "")
+	out, overrides, err := ApplyEnvOverrides("app", envTestConfig())
+	if err != nil || len(overrides) != 0 || !reflect.DeepEqual(out, envTestConfig()) {
+		t.Errorf("ApplyEnvOverrides = %+v, %v, %v, want the config unchanged", out, overrides, err)
+	}
+}
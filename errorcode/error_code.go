@@ -9,4 +9,8 @@ var (
 	ErrOpenFileFailed             = errors.New("open file failed")
 	ErrInvalidWriterDecoderObject = errors.New("invalid writer decoder object")
 	ErrInvalidWriterDecoderType   = errors.New("invalid writer decoder type")
+	ErrInvalidFormatterDecoder    = errors.New("invalid formatter decoder")
+	ErrUnknownOutput              = errors.New("unknown output")
+	ErrUnknownLevel               = errors.New("unknown level")
+	ErrWriterClosed               = errors.New("writer closed")
 )
@@ -0,0 +1,217 @@
+package zeus_log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GoroutinesKey is the key of the goroutine dump added to fatal logs, see
+// SetFatalStackDump.
+const GoroutinesKey = "goroutines"
+
+// defaultExitHookTimeout is how long Terminate waits for exit hooks and
+// Shutdown by default.
+const defaultExitHookTimeout = 5 * time.Second
+
+var (
+	exitMu          sync.Mutex
+	exitHooks       []exitHook
+	exitHookTimeout = defaultExitHookTimeout
+	// exitFunc is the exit function replaced by SetExitFunc, nil for the
+	// default one, which shuts down loggers and calls os.Exit.
+	exitFunc func(code int)
+
+	fatalStackDump atomic.Bool
+
+	terminateMu sync.Mutex
+	// terminated is closed when the running Terminate returns, nil if none is
+	// running.
+	terminated chan struct{}
+)
+
+// exitHook is a hook registered by RegisterExitHook.
+type exitHook struct {
+	name string
+	hook func(ctx context.Context)
+}
+
+// RegisterExitHook registers a hook which runs before the process exits on
+// fatal logs, like closing player sessions or flushing remote sinks. Hooks
+// run in the order they are registered, and share the timeout set by
+// SetExitHookTimeout through ctx, hooks left when it expires are skipped. The
+// name is used to report hooks which do not finish in time.
+func RegisterExitHook(name string, hook func(ctx context.Context)) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitHooks = append(exitHooks, exitHook{name: name, hook: hook})
+}
+
+// SetExitHookTimeout sets how long Terminate waits for exit hooks and
+// Shutdown together, 5 seconds by default.
+func SetExitHookTimeout(d time.Duration) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitHookTimeout = d
+}
+
+// SetExitFunc replaces the exit function called by Terminate after the exit
+// hooks, so that tests can assert on fatal logs. The default one shuts down
+// all loggers by Shutdown and calls os.Exit; f replaces both, so loggers keep
+// working after a fatal log in tests. A nil f restores the default one. It
+// returns a function restoring the previous one.
+func SetExitFunc(f func(code int)) (restore func()) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	prev := exitFunc
+	exitFunc = f
+	return func() {
+		exitMu.Lock()
+		defer exitMu.Unlock()
+		exitFunc = prev
+	}
+}
+
+// SetFatalStackDump sets whether the stacks of all goroutines are added to
+// fatal logs under GoroutinesKey.
+func SetFatalStackDump(enabled bool) {
+	fatalStackDump.Store(enabled)
+}
+
+// FatalStackDumpEnabled reports whether the stacks of all goroutines are added
+// to fatal logs.
+func FatalStackDumpEnabled() bool {
+	return fatalStackDump.Load()
+}
+
+// GoroutineDump returns the stacks of all goroutines, in the format of
+// runtime.Stack.
+func GoroutineDump() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// Terminate is called by loggers after writing fatal logs. It runs the exit
+// hooks, then calls the exit function with code, see SetExitFunc. The exit
+// hooks and shutting down loggers by the default exit function share the exit
+// hook timeout. Fatal logs written by the hooks exit immediately, without
+// running the hooks again. Fatal logs written concurrently by other goroutines
+// wait for the running Terminate, so with the default exit function they never
+// return, and the process exits with the code of the first one.
+func Terminate(code int) {
+	exitMu.Lock()
+	hooks := append([]exitHook(nil), exitHooks...)
+	timeout, exit := exitHookTimeout, exitFunc
+	exitMu.Unlock()
+
+	if inExitHook() {
+		if exit == nil {
+			exit = os.Exit
+		}
+		exit(code)
+		return
+	}
+	terminateMu.Lock()
+	for terminated != nil {
+		wait := terminated
+		terminateMu.Unlock()
+		<-wait
+		terminateMu.Lock()
+	}
+	terminated = make(chan struct{})
+	terminateMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, h := range hooks {
+		if err := ctx.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "log: exit hook %s skipped: %v\n", h.name, err)
+			continue
+		}
+		if !runExitHook(ctx, h) {
+			fmt.Fprintf(os.Stderr, "log: exit hook %s not finished: %v\n", h.name, ctx.Err())
+		}
+	}
+	if exit != nil {
+		// A replaced exit function returns, or panics to stop a test, then
+		// the next fatal log terminates again.
+		defer endTerminate()
+		exit(code)
+		return
+	}
+	if err := Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "log: shutdown on exit: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// endTerminate ends the running Terminate, and wakes up the waiting ones.
+func endTerminate() {
+	terminateMu.Lock()
+	defer terminateMu.Unlock()
+	close(terminated)
+	terminated = nil
+}
+
+// runExitHook runs the hook, and reports whether it finishes before ctx is
+// done. Panics of the hook are printed to stderr.
+func runExitHook(ctx context.Context, h exitHook) bool {
+	done := make(chan struct{})
+	go callExitHook(ctx, h, done)
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// callExitHook calls the hook and closes done. Panics of the hook are printed
+// to stderr. It's the function of the goroutines running hooks, see inExitHook.
+func callExitHook(ctx context.Context, h exitHook, done chan<- struct{}) {
+	defer close(done)
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "log: exit hook %s panic: %v\n", h.name, r)
+		}
+	}()
+	h.hook(ctx)
+}
+
+// callExitHookName is the function name of callExitHook.
+var callExitHookName = runtime.FuncForPC(reflect.ValueOf(callExitHook).Pointer()).Name()
+
+// inExitHook reports whether the caller runs in the goroutine of an exit hook,
+// whose fatal logs must not wait for the Terminate running the hook.
+func inExitHook() bool {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if f.Function == callExitHookName {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
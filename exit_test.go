@@ -0,0 +1,215 @@
+package zeus_log
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oyogames2023/zeus-log/plugin"
+)
+
+// resetExitHooks removes the exit hooks registered by a test.
+func resetExitHooks(t *testing.T) {
+	t.Cleanup(func() {
+		exitMu.Lock()
+		defer exitMu.Unlock()
+		exitHooks = nil
+		exitHookTimeout = defaultExitHookTimeout
+	})
+}
+
+// recordExits replaces the exit function to record the exit codes.
+func recordExits(t *testing.T) func() []int {
+	var (
+		mu    sync.Mutex
+		codes []int
+	)
+	t.Cleanup(SetExitFunc(func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		codes = append(codes, code)
+	}))
+	return func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), codes...)
+	}
+}
+
+func TestTerminateRunsHooksInOrder(t *testing.T) {
+	resetExitHooks(t)
+	exits := recordExits(t)
+	var order []string
+	for _, name := range []string{"sessions", "metrics", "remote"} {
+		name := name
+		RegisterExitHook(name, func(ctx context.Context) {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("hook %s ctx has no deadline", name)
+			}
+			order = append(order, name)
+		})
+	}
+	Terminate(1)
+	if want := []string{"sessions", "metrics", "remote"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks ran in %v, want %v", order, want)
+	}
+	if got := exits(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("exit codes = %v, want [1]", got)
+	}
+}
+
+func TestTerminateHookTimeout(t *testing.T) {
+	resetExitHooks(t)
+	exits := recordExits(t)
+	SetExitHookTimeout(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	RegisterExitHook("stuck", func(context.Context) { <-release })
+	laterRan := false
+	RegisterExitHook("later", func(context.Context) { laterRan = true })
+
+	start := time.Now()
+	Terminate(3)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Terminate took %v, want it bounded by the hook timeout", d)
+	}
+	if laterRan {
+		t.Error("hook after the timeout ran, want it skipped")
+	}
+	if got := exits(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("exit codes = %v, want [3]", got)
+	}
+}
+
+func TestTerminateReentrant(t *testing.T) {
+	resetExitHooks(t)
+	exits := recordExits(t)
+	runs := 0
+	RegisterExitHook("fatal in hook", func(context.Context) {
+		runs++
+		// Like a fatal log written by the hook.
+		Terminate(2)
+	})
+	Terminate(1)
+	if runs != 1 {
+		t.Errorf("hook ran %d times, want once", runs)
+	}
+	if got := exits(); !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("exit codes = %v, want [2 1]", got)
+	}
+}
+
+func TestTerminateConcurrent(t *testing.T) {
+	resetExitHooks(t)
+	exits := recordExits(t)
+	running, release := make(chan struct{}), make(chan struct{})
+	runs := 0
+	RegisterExitHook("slow", func(context.Context) {
+		runs++
+		if runs == 1 {
+			close(running)
+			<-release
+		}
+	})
+
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		Terminate(1)
+	}()
+	<-running
+	second := make(chan struct{})
+	go func() {
+		defer close(second)
+		Terminate(2)
+	}()
+	select {
+	case <-second:
+		t.Fatal("concurrent Terminate returned while the first one runs")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if got := exits(); len(got) != 0 {
+		t.Errorf("exit codes = %v before the hooks finish, want none", got)
+	}
+
+	close(release)
+	<-first
+	<-second
+	// With a replaced exit function, the waiting one terminates after the first.
+	if got := exits(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("exit codes = %v, want [1 2]", got)
+	}
+	if runs != 2 {
+		t.Errorf("hook ran %d times, want once per Terminate", runs)
+	}
+}
+
+func TestTerminateAfterPanickingExitFunc(t *testing.T) {
+	resetExitHooks(t)
+	t.Cleanup(SetExitFunc(func(code int) { panic(code) }))
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r != 1 {
+					t.Errorf("recovered %v, want the panic of the exit function", r)
+				}
+			}()
+			Terminate(1)
+		}()
+	}
+}
+
+// closeRecorder is a log factory recording whether it's closed.
+type closeRecorder struct {
+	closed bool
+}
+
+func (f *closeRecorder) Type() string {
+	return pluginType
+}
+
+func (f *closeRecorder) Setup(string, plugin.Decoder) error {
+	return nil
+}
+
+func (f *closeRecorder) Close(context.Context) error {
+	f.closed = true
+	return nil
+}
+
+func TestTerminateReplacedExitSkipsShutdown(t *testing.T) {
+	resetExitHooks(t)
+	recordExits(t)
+	f := &closeRecorder{}
+	recordSetup(f)
+	t.Cleanup(func() {
+		setupMu.Lock()
+		defer setupMu.Unlock()
+		setupFactories = nil
+	})
+	Terminate(1)
+	if f.closed {
+		t.Error("factory closed by Terminate with a replaced exit function")
+	}
+}
+
+func TestSetExitFuncRestore(t *testing.T) {
+	first := func(int) {}
+	restoreDefault := SetExitFunc(first)
+	restoreFirst := SetExitFunc(func(int) {})
+	restoreFirst()
+	exitMu.Lock()
+	got := reflect.ValueOf(exitFunc).Pointer()
+	exitMu.Unlock()
+	if got != reflect.ValueOf(first).Pointer() {
+		t.Error("restore did not restore the previous exit function")
+	}
+	restoreDefault()
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	if exitFunc != nil {
+		t.Error("restore did not restore the default exit function")
+	}
+}
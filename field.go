@@ -0,0 +1,221 @@
+package zeus_log
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// FieldType indicates how a Field is stored and which encoding a backend should
+// use for it.
+type FieldType uint8
+
+const (
+	// AnyType is a field of arbitrary type stored in Value, it's encoded by reflection.
+	AnyType FieldType = iota
+	// StringType is a field stored in String.
+	StringType
+	// Int64Type is a field stored in Integer.
+	Int64Type
+	// Uint64Type is a field stored in Integer as bits.
+	Uint64Type
+	// Float64Type is a field stored in Integer as IEEE 754 bits.
+	Float64Type
+	// BoolType is a field stored in Integer as 1 or 0.
+	BoolType
+	// DurationType is a field stored in Integer as nanoseconds.
+	DurationType
+	// TimeType is a field stored in Integer as unix nanoseconds, and Value holds
+	// its *time.Location.
+	TimeType
+	// TimeFullType is a field whose time.Time is stored in Value because it can
+	// not be represented by unix nanoseconds.
+	TimeFullType
+	// ErrorType is a field whose error is stored in Value.
+	ErrorType
+	// StringerType is a field whose fmt.Stringer is stored in Value.
+	StringerType
+	// BinaryType is a field whose []byte is stored in Value.
+	BinaryType
+	// ObjectType is a field whose ObjectMarshaler is stored in Value.
+	ObjectType
+	// ArrayType is a field whose ArrayMarshaler is stored in Value.
+	ArrayType
+)
+
+// The range of time.Time which can be represented by unix nanoseconds.
+var (
+	minTimeInt64 = time.Unix(0, math.MinInt64)
+	maxTimeInt64 = time.Unix(0, math.MaxInt64)
+)
+
+// ObjectEncoder is a strongly typed encoder of log objects, it's implemented by
+// the log backends.
+type ObjectEncoder interface {
+	AddString(key, value string)
+	AddInt64(key string, value int64)
+	AddUint64(key string, value uint64)
+	AddFloat64(key string, value float64)
+	AddBool(key string, value bool)
+	AddDuration(key string, value time.Duration)
+	AddTime(key string, value time.Time)
+	AddBinary(key string, value []byte)
+	AddObject(key string, marshaler ObjectMarshaler) error
+	AddArray(key string, marshaler ArrayMarshaler) error
+	// AddReflected uses reflection to encode value, it's slow and allocates.
+	AddReflected(key string, value any) error
+}
+
+// ArrayEncoder is a strongly typed encoder of log arrays, it's implemented by
+// the log backends.
+type ArrayEncoder interface {
+	AppendString(value string)
+	AppendInt64(value int64)
+	AppendUint64(value uint64)
+	AppendFloat64(value float64)
+	AppendBool(value bool)
+	AppendDuration(value time.Duration)
+	AppendTime(value time.Time)
+	AppendObject(marshaler ObjectMarshaler) error
+	AppendArray(marshaler ArrayMarshaler) error
+	// AppendReflected uses reflection to encode value, it's slow and allocates.
+	AppendReflected(value any) error
+}
+
+// ObjectMarshaler allows user defined types to encode themselves as log objects
+// without reflection.
+type ObjectMarshaler interface {
+	MarshalLogObject(enc ObjectEncoder) error
+}
+
+// ObjectMarshalerFunc is a function which implements ObjectMarshaler.
+type ObjectMarshalerFunc func(enc ObjectEncoder) error
+
+// MarshalLogObject calls f(enc).
+func (f ObjectMarshalerFunc) MarshalLogObject(enc ObjectEncoder) error {
+	return f(enc)
+}
+
+// ArrayMarshaler allows user defined types to encode themselves as log arrays
+// without reflection.
+type ArrayMarshaler interface {
+	MarshalLogArray(enc ArrayEncoder) error
+}
+
+// ArrayMarshalerFunc is a function which implements ArrayMarshaler.
+type ArrayMarshalerFunc func(enc ArrayEncoder) error
+
+// MarshalLogArray calls f(enc).
+func (f ArrayMarshalerFunc) MarshalLogArray(enc ArrayEncoder) error {
+	return f(enc)
+}
+
+// Any constructs a field of arbitrary type, it's encoded by reflection.
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String constructs a field with a string value.
+func String(key string, value string) Field {
+	return Field{Key: key, Type: StringType, String: value}
+}
+
+// Int constructs a field with an int value.
+func Int(key string, value int) Field {
+	return Int64(key, int64(value))
+}
+
+// Int64 constructs a field with an int64 value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: Int64Type, Integer: value}
+}
+
+// Uint64 constructs a field with an uint64 value.
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, Type: Uint64Type, Integer: int64(value)}
+}
+
+// Float64 constructs a field with a float64 value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Type: Float64Type, Integer: int64(math.Float64bits(value))}
+}
+
+// Bool constructs a field with a bool value.
+func Bool(key string, value bool) Field {
+	var i int64
+	if value {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: i}
+}
+
+// Duration constructs a field with a time.Duration value.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Integer: int64(value)}
+}
+
+// Time constructs a field with a time.Time value.
+func Time(key string, value time.Time) Field {
+	if value.Before(minTimeInt64) || value.After(maxTimeInt64) {
+		return Field{Key: key, Type: TimeFullType, Value: value}
+	}
+	return Field{Key: key, Type: TimeType, Integer: value.UnixNano(), Value: value.Location()}
+}
+
+// Err constructs a field with the key "error" and an error value.
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+// NamedErr constructs a field with an error value.
+func NamedErr(key string, err error) Field {
+	return Field{Key: key, Type: ErrorType, Value: err}
+}
+
+// Stringer constructs a field whose value is the result of value.String(),
+// which is called lazily only if the log is written.
+func Stringer(key string, value fmt.Stringer) Field {
+	return Field{Key: key, Type: StringerType, Value: value}
+}
+
+// Binary constructs a field with opaque binary data.
+func Binary(key string, value []byte) Field {
+	return Field{Key: key, Type: BinaryType, Value: value}
+}
+
+// Object constructs a field with an ObjectMarshaler value.
+func Object(key string, value ObjectMarshaler) Field {
+	return Field{Key: key, Type: ObjectType, Value: value}
+}
+
+// Array constructs a field with an ArrayMarshaler value.
+func Array(key string, value ArrayMarshaler) Field {
+	return Field{Key: key, Type: ArrayType, Value: value}
+}
+
+// Interface returns the value of the field as a plain Go value. It's useful for
+// backends which have no native encoding for a field type.
+func (f Field) Interface() any {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case Int64Type:
+		return f.Integer
+	case Uint64Type:
+		return uint64(f.Integer)
+	case Float64Type:
+		return math.Float64frombits(uint64(f.Integer))
+	case BoolType:
+		return f.Integer == 1
+	case DurationType:
+		return time.Duration(f.Integer)
+	case TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Value.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return t
+	default:
+		return f.Value
+	}
+}
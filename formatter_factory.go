@@ -0,0 +1,64 @@
+package zeus_log
+
+import (
+	"fmt"
+	"github.com/oyogames2023/zeus-log/plugin"
+	"sort"
+	"sync"
+)
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[string]plugin.Factory)
+)
+
+// RegisterFormatter registers log formatter, which is referred to by the
+// formatter of outputs. The options block of the format config is decoded by
+// the formatter itself. It panics if a formatter is already registered by the
+// name, use RegisterFormatterE to get the error.
+func RegisterFormatter(name string, formatter plugin.Factory) {
+	if err := RegisterFormatterE(name, formatter); err != nil {
+		panic(err.Error())
+	}
+}
+
+// RegisterFormatterE is RegisterFormatter which returns an error instead of
+// panic if a formatter is already registered by the name.
+func RegisterFormatterE(name string, formatter plugin.Factory) error {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	if _, dup := formatters[name]; dup {
+		return fmt.Errorf("log: formatter %s registered twice", name)
+	}
+	formatters[name] = formatter
+	return nil
+}
+
+// UnregisterFormatter removes the log formatter, and reports whether it was
+// registered.
+func UnregisterFormatter(name string) bool {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	_, ok := formatters[name]
+	delete(formatters, name)
+	return ok
+}
+
+// GetFormatter gets log formatter, returns nil if not exist.
+func GetFormatter(name string) plugin.Factory {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	return formatters[name]
+}
+
+// formatterNames returns the sorted names of registered formatters.
+func formatterNames() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,118 @@
+package zeus_log
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/oyogames2023/zeus-log/plugin"
+)
+
+type stubFormatter struct{}
+
+func (f stubFormatter) Type() string {
+	return "log"
+}
+
+func (f stubFormatter) Setup(string, plugin.Decoder) error {
+	return nil
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	const name = "test-registry"
+	if err := RegisterFormatterE(name, stubFormatter{}); err != nil {
+		t.Fatalf("RegisterFormatterE: %v", err)
+	}
+	t.Cleanup(func() { UnregisterFormatter(name) })
+
+	if f := GetFormatter(name); f != (stubFormatter{}) {
+		t.Errorf("GetFormatter = %v, want the registered one", f)
+	}
+	err := RegisterFormatterE(name, stubFormatter{})
+	if err == nil || !strings.Contains(err.Error(), "formatter test-registry registered twice") {
+		t.Errorf("second RegisterFormatterE err = %v, want registered twice", err)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "registered twice") {
+				t.Errorf("second RegisterFormatter recovered %v, want panic of registered twice", r)
+			}
+		}()
+		RegisterFormatter(name, stubFormatter{})
+	}()
+	names := formatterNames()
+	if !contains(names, name) {
+		t.Errorf("formatterNames = %q, want %s listed", names, name)
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("formatterNames = %q, want sorted", names)
+	}
+
+	if !UnregisterFormatter(name) {
+		t.Error("UnregisterFormatter = false, want true")
+	}
+	if UnregisterFormatter(name) {
+		t.Error("second UnregisterFormatter = true, want false")
+	}
+	if f := GetFormatter(name); f != nil {
+		t.Errorf("GetFormatter after unregistering = %v, want nil", f)
+	}
+}
+
+func TestValidateUnknownFormatter(t *testing.T) {
+	const name = "test-listed"
+	RegisterFormatter(name, stubFormatter{})
+	t.Cleanup(func() { UnregisterFormatter(name) })
+
+	c := &OutputConfig{Formatter: "xml"}
+	var ce *ConfigError
+	err := c.Validate()
+	for _, e := range unwrapAll(err) {
+		if errors.As(e, &ce) && ce.Field == "formatter" {
+			break
+		}
+		ce = nil
+	}
+	if ce == nil {
+		t.Fatalf("Validate err = %v, want formatter error", err)
+	}
+	if ce.Value != "xml" || ce.Reason != "not registered" || !reflect.DeepEqual(ce.Allowed, formatterNames()) {
+		t.Errorf("error = %+v, want xml not registered with the registered formatters allowed", ce)
+	}
+	if !contains(ce.Allowed, name) {
+		t.Errorf("allowed = %q, want %s listed", ce.Allowed, name)
+	}
+	if want := `log: invalid config: formatter: "xml": not registered, allowed: `; !strings.HasPrefix(ce.Error(), want) {
+		t.Errorf("error = %q, want prefix %q", ce.Error(), want)
+	}
+
+	c.Formatter = name
+	for _, e := range unwrapAll(c.Validate()) {
+		if errors.As(e, &ce) && ce.Field == "formatter" {
+			t.Errorf("registered formatter err = %v", e)
+		}
+	}
+}
+
+// unwrapAll returns the errors joined in err.
+func unwrapAll(err error) []error {
+	if joined, ok := err.(interface{ WrappedErrors() []error }); ok {
+		return joined.WrappedErrors()
+	}
+	if err == nil {
+		return nil
+	}
+	return []error{err}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
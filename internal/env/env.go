@@ -1,5 +1,18 @@
 package env
 
+// Environment variables read by zeus-log. The ZEUS_LOG_* variables override
+// the log config, each of them also has a per logger form made by appending
+// the logger name, upper-cased with non-alphanumeric characters replaced by
+// '_', like ZEUS_LOG_LEVEL_ACCESS for the logger "access".
 const (
 	EnabledTraceLog = "ENABLED_LOG_TRACE"
+
+	// LogLevel overrides the level of all outputs, like "warn".
+	LogLevel = "ZEUS_LOG_LEVEL"
+	// LogFormat overrides the formatter of all outputs, like "json".
+	LogFormat = "ZEUS_LOG_FORMAT"
+	// LogPath overrides the log path of file outputs, like "/data/logs".
+	LogPath = "ZEUS_LOG_PATH"
+	// LogColor turns the color of console outputs on or off, like "0" or "1".
+	LogColor = "ZEUS_LOG_COLOR"
 )
@@ -4,13 +4,87 @@ import (
 	"context"
 	"github.com/oyogames2023/zeus-log/internal/env"
 	"os"
+	"reflect"
+	"sync/atomic"
 )
 
 type (
 	loggerKey struct{}
 )
 
-var traceEnabled = traceEnableFromEnv()
+// NewContext returns a copy of ctx carrying logger. The *Context functions and
+// WithContext use it instead of the default Logger for the returned context.
+// The logger is expected to be ready for direct use, like the one returned by
+// With or WithFields. A nil ctx is taken as context.Background.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ol, ok := logger.(OptionLogger); ok {
+		logger = ol.WithOptions(WithAdditionalCallerSkip(1))
+	}
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the Logger carried by ctx, or the default Logger if there
+// is none or ctx is nil. The returned logger is ready for direct use.
+func FromContext(ctx context.Context) Logger {
+	logger, ok := contextLogger(ctx)
+	if !ok {
+		return directDefaultLogger()
+	}
+	if ol, ok := logger.(OptionLogger); ok {
+		return ol.WithOptions(WithAdditionalCallerSkip(-1))
+	}
+	return logger
+}
+
+// directLogger is a default Logger adjusted for direct use.
+type directLogger struct {
+	from   Logger
+	logger Logger
+}
+
+// directDefault caches the default Logger adjusted for direct use, so that
+// FromContext does not allocate when ctx carries no logger.
+var directDefault atomic.Pointer[directLogger]
+
+// directDefaultLogger returns the default Logger with the caller skip fitting
+// direct use instead of the package level functions.
+func directDefaultLogger() Logger {
+	l := GetDefaultLogger()
+	ol, ok := l.(OptionLogger)
+	if !ok {
+		return l
+	}
+	cacheable := reflect.TypeOf(l).Comparable()
+	if d := directDefault.Load(); cacheable && d != nil && d.from == l {
+		return d.logger
+	}
+	d := &directLogger{from: l, logger: ol.WithOptions(WithAdditionalCallerSkip(-1))}
+	if cacheable {
+		directDefault.Store(d)
+	}
+	return d.logger
+}
+
+// contextLogger returns the Logger carried by ctx, ctx may be nil.
+func contextLogger(ctx context.Context) (Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	return l, ok
+}
+
+// loggerFromContext returns the Logger carried by ctx, falls back to the default
+// Logger. The caller skip of the returned logger fits the package level functions.
+func loggerFromContext(ctx context.Context) Logger {
+	if l, ok := contextLogger(ctx); ok {
+		return l
+	}
+	return GetDefaultLogger()
+}
 
 // traceEnableFromEnv checks whether trace is enabled by reading from environment.
 // Close trace if empty or zero, open trace if not zero, default as closed.
@@ -26,15 +100,37 @@ func traceEnableFromEnv() bool {
 }
 
 // SetLevel sets log level for different output which may be "trace", "debug", "info".
+// Outputs are addressed by name, by writer type, by index, or AllOutputs. Unknown
+// outputs are ignored, use SetOutputLevel to get the error.
 func SetLevel(output string, level Level) {
-	GetDefaultLogger().SetLevel(output, level)
+	_ = SetOutputLevel(output, level)
 }
 
-// GetLevel gets log level for different output.
+// GetLevel gets log level for different output. It's LevelOff for unknown
+// outputs, use GetOutputLevel to get the error.
 func GetLevel(output string) Level {
+	level, _ := GetOutputLevel(output)
+	return level
+}
+
+// SetOutputLevel sets the log level of output of the default Logger. Outputs are
+// addressed by name, by writer type, by index, or AllOutputs, and an error
+// wrapping errorcode.ErrUnknownOutput is returned if none matches.
+func SetOutputLevel(output string, level Level) error {
+	return GetDefaultLogger().SetLevel(output, level)
+}
+
+// GetOutputLevel gets the log level of output of the default Logger, the lowest
+// one if several outputs match.
+func GetOutputLevel(output string) (Level, error) {
 	return GetDefaultLogger().GetLevel(output)
 }
 
+// OutputLevels lists every output of the default Logger with its current level.
+func OutputLevels() []OutputLevel {
+	return GetDefaultLogger().OutputLevels()
+}
+
 func With(args ...any) Logger {
 	if ol, ok := GetDefaultLogger().(OptionLogger); ok {
 		return ol.WithOptions(WithAdditionalCallerSkip(-1)).With(args...)
@@ -50,52 +146,41 @@ func WithFields(fields ...Field) Logger {
 }
 
 func WithFieldsContext(ctx context.Context, fields ...Field) Logger {
-	var logger Logger
-	var ok bool
-	logger, ok = ctx.Value(loggerKey{}).(Logger)
+	logger, ok := contextLogger(ctx)
 	if !ok {
 		return WithFields(fields...)
 	}
-	var ol OptionLogger
-	if ol, ok = logger.(OptionLogger); ok {
+	if ol, ok := logger.(OptionLogger); ok {
 		return ol.WithOptions(WithAdditionalCallerSkip(-1)).WithFields(fields...)
 	}
 	return logger.WithFields(fields...)
 }
 
 func WithContext(ctx context.Context, args ...any) Logger {
-	var logger Logger
-	var ok bool
-	logger, ok = ctx.Value(loggerKey{}).(Logger)
+	logger, ok := contextLogger(ctx)
 	if !ok {
 		return With(args...)
 	}
-	var ol OptionLogger
-	if ol, ok = logger.(OptionLogger); ok {
+	if ol, ok := logger.(OptionLogger); ok {
 		return ol.WithOptions(WithAdditionalCallerSkip(-1)).With(args...)
 	}
 	return logger.With(args...)
 }
 
 // Trace logs to TRACE log. Arguments are handled in the manner of fmt.Print.
+// Trace logs are printed only if enabled, see IsTraceEnabled.
 func Trace(args ...any) {
-	if traceEnabled {
-		GetDefaultLogger().Trace(args...)
-	}
+	GetDefaultLogger().Trace(args...)
 }
 
 // Tracef logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
 func Tracef(format string, args ...any) {
-	if traceEnabled {
-		GetDefaultLogger().Tracef(format, args...)
-	}
+	GetDefaultLogger().Tracef(format, args...)
 }
 
 // Traceln logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
 func Traceln(args ...any) {
-	if traceEnabled {
-		GetDefaultLogger().Traceln(args...)
-	}
+	GetDefaultLogger().Traceln(args...)
 }
 
 // Debug logs to DEBUG log. Arguments are handled in the manner of fmt.Print.
@@ -155,38 +240,132 @@ func Fatalf(format string, args ...interface{}) {
 	GetDefaultLogger().Fatalf(format, args...)
 }
 
+// Log logs msg with fields at level to the default Logger.
+func Log(level Level, msg string, fields ...Field) {
+	GetDefaultLogger().Log(level, msg, fields...)
+}
+
+// TraceFields logs msg with fields to TRACE log.
+func TraceFields(msg string, fields ...Field) {
+	GetDefaultLogger().TraceFields(msg, fields...)
+}
+
+// DebugFields logs msg with fields to DEBUG log.
+func DebugFields(msg string, fields ...Field) {
+	GetDefaultLogger().DebugFields(msg, fields...)
+}
+
+// InfoFields logs msg with fields to INFO log.
+func InfoFields(msg string, fields ...Field) {
+	GetDefaultLogger().InfoFields(msg, fields...)
+}
+
+// WarnFields logs msg with fields to WARNING log.
+func WarnFields(msg string, fields ...Field) {
+	GetDefaultLogger().WarnFields(msg, fields...)
+}
+
+// ErrorFields logs msg with fields to ERROR log.
+func ErrorFields(msg string, fields ...Field) {
+	GetDefaultLogger().ErrorFields(msg, fields...)
+}
+
+// FatalFields logs msg with fields to FATAL log.
+func FatalFields(msg string, fields ...Field) {
+	GetDefaultLogger().FatalFields(msg, fields...)
+}
+
+// PanicFields logs msg with fields to PANIC log.
+func PanicFields(msg string, fields ...Field) {
+	GetDefaultLogger().PanicFields(msg, fields...)
+}
+
 // TraceContext logs to TRACE log. Arguments are handled in the manner of fmt.Print.
 func TraceContext(ctx context.Context, args ...any) {
-	if !traceEnabled {
-		return
-	}
-	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
-		l.Trace(args...)
-		return
-	}
-	GetDefaultLogger().Trace(args...)
+	loggerFromContext(ctx).Trace(args...)
 }
 
 // TraceContextf logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
 func TraceContextf(ctx context.Context, format string, args ...any) {
-	if !traceEnabled {
-		return
-	}
-	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
-		l.Tracef(format, args...)
-		return
-	}
-	GetDefaultLogger().Tracef(format, args...)
+	loggerFromContext(ctx).Tracef(format, args...)
 }
 
-// TraceContextln logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
+// TraceContextln logs to TRACE log. Arguments are handled in the manner of fmt.Println.
 func TraceContextln(ctx context.Context, args ...any) {
-	if !traceEnabled {
-		return
-	}
-	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
-		l.Traceln(args...)
-		return
-	}
-	GetDefaultLogger().Traceln(args...)
+	loggerFromContext(ctx).Traceln(args...)
+}
+
+// DebugContext logs to DEBUG log. Arguments are handled in the manner of fmt.Print.
+func DebugContext(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Debug(args...)
+}
+
+// DebugContextf logs to DEBUG log. Arguments are handled in the manner of fmt.Printf.
+func DebugContextf(ctx context.Context, format string, args ...any) {
+	loggerFromContext(ctx).Debugf(format, args...)
+}
+
+// DebugContextln logs to DEBUG log. Arguments are handled in the manner of fmt.Println.
+func DebugContextln(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Debugln(args...)
+}
+
+// InfoContext logs to INFO log. Arguments are handled in the manner of fmt.Print.
+func InfoContext(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Info(args...)
+}
+
+// InfoContextf logs to INFO log. Arguments are handled in the manner of fmt.Printf.
+func InfoContextf(ctx context.Context, format string, args ...any) {
+	loggerFromContext(ctx).Infof(format, args...)
+}
+
+// InfoContextln logs to INFO log. Arguments are handled in the manner of fmt.Println.
+func InfoContextln(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Infoln(args...)
+}
+
+// WarnContext logs to WARNING log. Arguments are handled in the manner of fmt.Print.
+func WarnContext(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Warn(args...)
+}
+
+// WarnContextf logs to WARNING log. Arguments are handled in the manner of fmt.Printf.
+func WarnContextf(ctx context.Context, format string, args ...any) {
+	loggerFromContext(ctx).Warnf(format, args...)
+}
+
+// WarnContextln logs to WARNING log. Arguments are handled in the manner of fmt.Println.
+func WarnContextln(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Warnln(args...)
+}
+
+// ErrorContext logs to ERROR log. Arguments are handled in the manner of fmt.Print.
+func ErrorContext(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Error(args...)
+}
+
+// ErrorContextf logs to ERROR log. Arguments are handled in the manner of fmt.Printf.
+func ErrorContextf(ctx context.Context, format string, args ...any) {
+	loggerFromContext(ctx).Errorf(format, args...)
+}
+
+// ErrorContextln logs to ERROR log. Arguments are handled in the manner of fmt.Println.
+func ErrorContextln(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Errorln(args...)
+}
+
+// FatalContext logs to FATAL log. Arguments are handled in the manner of fmt.Print.
+func FatalContext(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Fatal(args...)
+}
+
+// FatalContextf logs to FATAL log. Arguments are handled in the manner of fmt.Printf.
+func FatalContextf(ctx context.Context, format string, args ...any) {
+	loggerFromContext(ctx).Fatalf(format, args...)
+}
+
+// FatalContextln logs to FATAL log. Arguments are handled in the manner of fmt.Println.
+func FatalContextln(ctx context.Context, args ...any) {
+	loggerFromContext(ctx).Fatalln(args...)
 }
@@ -0,0 +1,114 @@
+package zap
+
+import (
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// mainModulePath and mainPackagePath are the paths of the main module and
+// the main package from the build info, which are empty if unknown.
+var mainModulePath, mainPackagePath = func() (string, string) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ""
+	}
+	return bi.Main.Path, bi.Path
+}()
+
+// moduleRelativeFile returns the file path of the caller relative to the
+// main module, like "internal/pkg/file.go". Callers out of the main module
+// have their package paths, like "github.com/org/lib/pkg/file.go".
+func moduleRelativeFile(caller zapcore.EntryCaller) string {
+	pkg, _ := splitFunction(caller.Function)
+	if pkg == "" {
+		return caller.TrimmedPath()
+	}
+	if pkg == "main" && mainPackagePath != "" {
+		pkg = mainPackagePath
+	}
+	dir := pkg
+	switch {
+	case mainModulePath == "":
+	case pkg == mainModulePath:
+		dir = ""
+	case strings.HasPrefix(pkg, mainModulePath+"/"):
+		dir = pkg[len(mainModulePath)+1:]
+	}
+	file := filepath.Base(caller.File)
+	if dir != "" {
+		file = dir + "/" + file
+	}
+	return file
+}
+
+// splitFunction splits a function name like "github.com/org/app/pkg.(*T).Method"
+// into its package path "github.com/org/app/pkg" and name "(*T).Method".
+func splitFunction(function string) (pkg, name string) {
+	slash := strings.LastIndexByte(function, '/')
+	dot := strings.IndexByte(function[slash+1:], '.')
+	if dot < 0 {
+		return "", function
+	}
+	return function[:slash+1+dot], function[slash+2+dot:]
+}
+
+// formatFunction formats a function name by the function format.
+func formatFunction(function, format string) string {
+	switch format {
+	case xlog.FunctionFormatPackage:
+		return function[strings.LastIndexByte(function, '/')+1:]
+	case xlog.FunctionFormatMethod:
+		_, name := splitFunction(function)
+		if strings.HasPrefix(name, "(") {
+			if i := strings.Index(name, ")."); i >= 0 {
+				return name[i+2:]
+			}
+		}
+		return name
+	default:
+		return function
+	}
+}
+
+// callerEncoderFor returns the caller encoder of the caller format. Module
+// relative paths are set by callerFormatEncoder, and encoded as full paths.
+func callerEncoderFor(format string) zapcore.CallerEncoder {
+	switch format {
+	case xlog.CallerFormatFull, xlog.CallerFormatModuleRelative:
+		return zapcore.FullCallerEncoder
+	default:
+		return zapcore.ShortCallerEncoder
+	}
+}
+
+// callerFormatEncoder formats the callers of entries before they are encoded.
+// Module relative paths are computed from function names, so they are set
+// before function names are formatted. zapcore.EncoderConfig has no encoder of
+// function names.
+type callerFormatEncoder struct {
+	zapcore.Encoder
+	moduleRelative bool
+	functionFormat string
+}
+
+// Clone implements zapcore.Encoder.
+func (e *callerFormatEncoder) Clone() zapcore.Encoder {
+	clone := *e
+	clone.Encoder = e.Encoder.Clone()
+	return &clone
+}
+
+// EncodeEntry implements zapcore.Encoder.
+func (e *callerFormatEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if ent.Caller.Defined {
+		if e.moduleRelative {
+			ent.Caller.File = moduleRelativeFile(ent.Caller)
+		}
+		ent.Caller.Function = formatFunction(ent.Caller.Function, e.functionFormat)
+	}
+	return e.Encoder.EncodeEntry(ent, fields)
+}
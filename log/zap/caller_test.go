@@ -0,0 +1,161 @@
+package zap
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+)
+
+// callerTester logs from a method, so that the function formats differ.
+type callerTester struct {
+	l xlog.Logger
+}
+
+func (c *callerTester) log(msg string) {
+	c.l.Info(msg)
+}
+
+func TestSplitFunction(t *testing.T) {
+	for _, tt := range []struct {
+		function, pkg, name string
+	}{
+		{"github.com/org/app/pkg.(*T).Method", "github.com/org/app/pkg", "(*T).Method"},
+		{"github.com/org/app/pkg.Func.func1", "github.com/org/app/pkg", "Func.func1"},
+		{"github.com/org/app.v2/pkg.Func", "github.com/org/app.v2/pkg", "Func"},
+		{"main.main", "main", "main"},
+		{"nodot", "", "nodot"},
+	} {
+		pkg, name := splitFunction(tt.function)
+		if pkg != tt.pkg || name != tt.name {
+			t.Errorf("splitFunction(%q) = %q, %q, want %q, %q", tt.function, pkg, name, tt.pkg, tt.name)
+		}
+	}
+}
+
+func TestFormatFunction(t *testing.T) {
+	const method = "github.com/org/app/pkg.(*T).Method"
+	const function = "github.com/org/app/pkg.Func.func1"
+	for _, tt := range []struct {
+		function, format, want string
+	}{
+		{method, "", method},
+		{method, xlog.FunctionFormatFull, method},
+		{method, xlog.FunctionFormatPackage, "pkg.(*T).Method"},
+		{method, xlog.FunctionFormatMethod, "Method"},
+		{"github.com/org/app/pkg.T.Method", xlog.FunctionFormatMethod, "T.Method"},
+		{function, xlog.FunctionFormatPackage, "pkg.Func.func1"},
+		{function, xlog.FunctionFormatMethod, "Func.func1"},
+	} {
+		if got := formatFunction(tt.function, tt.format); got != tt.want {
+			t.Errorf("formatFunction(%q, %q) = %q, want %q", tt.function, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestModuleRelativeFile(t *testing.T) {
+	if mainModulePath == "" {
+		t.Skip("no build info")
+	}
+	for _, tt := range []struct {
+		function, want string
+	}{
+		{mainModulePath + "/internal/pkg.Func", "internal/pkg/file.go"},
+		{mainModulePath + ".Func", "file.go"},
+		{"github.com/other/lib/pkg.(*T).Method", "github.com/other/lib/pkg/file.go"},
+	} {
+		caller := zapcore.EntryCaller{Defined: true, Function: tt.function, File: "/src/any/file.go", Line: 12}
+		if got := moduleRelativeFile(caller); got != tt.want {
+			t.Errorf("moduleRelativeFile(%q) = %q, want %q", tt.function, got, tt.want)
+		}
+	}
+}
+
+func TestCallerAndFunctionFormat(t *testing.T) {
+	pkgPath := mainModulePath + "/log/zap"
+	for _, tt := range []struct {
+		name           string
+		callerFormat   string
+		functionFormat string
+		// caller is the wanted caller without the line, and empty if there
+		// should be none.
+		caller   string
+		function string
+	}{
+		{
+			name:   "default",
+			caller: "zap/caller_test.go",
+		},
+		{
+			name:           "short caller, full function",
+			callerFormat:   xlog.CallerFormatShort,
+			functionFormat: xlog.FunctionFormatFull,
+			caller:         "zap/caller_test.go",
+			function:       pkgPath + ".(*callerTester).log",
+		},
+		{
+			name:           "full caller, package function",
+			callerFormat:   xlog.CallerFormatFull,
+			functionFormat: xlog.FunctionFormatPackage,
+			caller:         filepath.ToSlash(callerTestFile(t)),
+			function:       "zap.(*callerTester).log",
+		},
+		{
+			name:           "module-relative caller, method function",
+			callerFormat:   xlog.CallerFormatModuleRelative,
+			functionFormat: xlog.FunctionFormatMethod,
+			caller:         "log/zap/caller_test.go",
+			function:       "log",
+		},
+		{
+			name:           "no caller",
+			callerFormat:   xlog.CallerFormatNone,
+			functionFormat: xlog.FunctionFormatMethod,
+			function:       "log",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if mainModulePath == "" && tt.callerFormat == xlog.CallerFormatModuleRelative {
+				t.Skip("no build info")
+			}
+			l := newTestLog(t, xlog.Config{{
+				Name:      "caller-out",
+				Writer:    testWriter,
+				Formatter: xlog.FormatterJSON,
+				FormatConfig: xlog.FormatConfig{
+					CallerFormat:   tt.callerFormat,
+					FunctionFormat: tt.functionFormat,
+				},
+			}})
+			(&callerTester{l: l}).log("msg")
+
+			e := testEntries(t, "caller-out")[0]
+			caller, hasCaller := e["C"].(string)
+			switch {
+			case tt.caller == "" && hasCaller:
+				t.Errorf("caller = %q, want none", caller)
+			case tt.caller != "" && !strings.HasPrefix(caller, tt.caller+":"):
+				t.Errorf("caller = %q, want %s:<line>", caller, tt.caller)
+			}
+			function, hasFunction := e["F"].(string)
+			switch {
+			case tt.function == "" && hasFunction:
+				t.Errorf("function = %q, want none", function)
+			case tt.function != "" && function != tt.function:
+				t.Errorf("function = %q, want %q", function, tt.function)
+			}
+		})
+	}
+}
+
+// callerTestFile returns the absolute path of this file.
+func callerTestFile(t *testing.T) string {
+	t.Helper()
+	path, err := filepath.Abs("caller_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
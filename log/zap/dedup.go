@@ -0,0 +1,274 @@
+package zap
+
+import (
+	"encoding/binary"
+	"fmt"
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+	"hash/maphash"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dedupSeed is the seed of the keys of logs, see dedupCore.key.
+var dedupSeed = maphash.MakeSeed()
+
+// deduper is the state of suppressing duplicate logs of an output, shared by
+// the loggers derived from it.
+type deduper struct {
+	window time.Duration
+	// base is the core of the output without context fields, which repeat
+	// summaries are written to.
+	base zapcore.Core
+
+	// mu is held while logs and summaries are written, so that summaries
+	// follow the logs they repeat.
+	mu sync.Mutex
+	// last is the last written log, nil if none.
+	last *dedupEntry
+	// repeated is the number of logs suppressed since the last summary.
+	repeated int
+	timer    *time.Timer
+	// timers counts the timers which are started and not stopped, including
+	// the ones writing their summaries.
+	timers  sync.WaitGroup
+	stopped bool
+}
+
+// dedupEntry is a written log, kept to compare the next ones with.
+type dedupEntry struct {
+	key    uint64
+	ent    zapcore.Entry
+	ctx    []zapcore.Field
+	fields []zapcore.Field
+}
+
+// newDedupCore wraps core to suppress consecutive duplicate logs. The pending
+// summary is written when the window passes, until the returned deduper is
+// stopped.
+func newDedupCore(core zapcore.Core, cfg xlog.DedupConfig) (zapcore.Core, *deduper) {
+	d := &deduper{window: cfg.Window, base: core}
+	return &dedupCore{Core: core, d: d}, d
+}
+
+// isLast reports whether a log of the core is identical to the last one. The
+// keys are compared first, then the logs, so that a collision of keys does not
+// suppress a different log. It must be called with mu held.
+func (d *deduper) isLast(c *dedupCore, key uint64, ent zapcore.Entry, fields []zapcore.Field) bool {
+	last := d.last
+	return last != nil && last.key == key &&
+		last.ent.Level == ent.Level &&
+		last.ent.LoggerName == ent.LoggerName &&
+		last.ent.Message == ent.Message &&
+		fieldsEqual(last.ctx, c.ctx) &&
+		fieldsEqual(last.fields, fields)
+}
+
+// startTimer writes the pending summary when the window passes. It must be
+// called with mu held.
+func (d *deduper) startTimer() {
+	if d.timer != nil || d.stopped {
+		return
+	}
+	d.timers.Add(1)
+	d.timer = time.AfterFunc(d.window, func() {
+		defer d.timers.Done()
+		if err := d.flush(); err != nil {
+			fmt.Printf("log: write dedup summary err: %+v\n", err)
+		}
+	})
+}
+
+// writeRepeated stops the timer, and writes the summary of the logs
+// suppressed since the last one and resets their count. It must be called
+// with mu held.
+func (d *deduper) writeRepeated() error {
+	if d.timer != nil {
+		if d.timer.Stop() {
+			d.timers.Done()
+		}
+		d.timer = nil
+	}
+	n := d.repeated
+	if n == 0 {
+		return nil
+	}
+	d.repeated = 0
+	ent := d.last.ent
+	return d.base.Write(zapcore.Entry{
+		Level:      ent.Level,
+		Time:       time.Now(),
+		LoggerName: ent.LoggerName,
+		Message:    fmt.Sprintf("last message repeated %d times", n),
+	}, nil)
+}
+
+// flush writes the pending summary.
+func (d *deduper) flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.writeRepeated()
+}
+
+// stop stops the timer and writes the pending summary. No summary is written
+// to the output after it returns.
+func (d *deduper) stop() error {
+	d.mu.Lock()
+	d.stopped = true
+	err := d.writeRepeated()
+	d.mu.Unlock()
+	d.timers.Wait()
+	return err
+}
+
+// dedupCore suppresses the logs identical to the last one of the output.
+type dedupCore struct {
+	zapcore.Core
+	d *deduper
+	// ctx are the context fields, and fieldsKey their hash.
+	ctx       []zapcore.Field
+	fieldsKey uint64
+}
+
+// With implements zapcore.Core.
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	var h maphash.Hash
+	h.SetSeed(dedupSeed)
+	writeUint64(&h, c.fieldsKey)
+	hashFields(&h, fields)
+	return &dedupCore{
+		Core:      c.Core.With(fields),
+		d:         c.d,
+		ctx:       append(c.ctx[:len(c.ctx):len(c.ctx)], fields...),
+		fieldsKey: h.Sum64(),
+	}
+}
+
+// Check implements zapcore.Core. Duplicates are checked in Write, where the
+// fields of logs are known.
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// key returns the hash of the level, logger name, message and fields of a log.
+func (c *dedupCore) key(ent zapcore.Entry, fields []zapcore.Field) uint64 {
+	var h maphash.Hash
+	h.SetSeed(dedupSeed)
+	writeUint64(&h, c.fieldsKey)
+	_ = h.WriteByte(byte(ent.Level))
+	writeString(&h, ent.LoggerName)
+	writeString(&h, ent.Message)
+	hashFields(&h, fields)
+	return h.Sum64()
+}
+
+// Write implements zapcore.Core.
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := c.key(ent, fields)
+
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if !c.d.stopped && c.d.isLast(c, key, ent, fields) {
+		c.d.repeated++
+		c.d.startTimer()
+		return nil
+	}
+	summaryErr := c.d.writeRepeated()
+	c.d.last = &dedupEntry{
+		key:    key,
+		ent:    ent,
+		ctx:    c.ctx,
+		fields: append([]zapcore.Field(nil), fields...),
+	}
+	if err := c.Core.Write(ent, fields); err != nil {
+		return err
+	}
+	return summaryErr
+}
+
+// Sync implements zapcore.Core. The pending summary is written first.
+func (c *dedupCore) Sync() error {
+	summaryErr := c.d.flush()
+	if err := c.Core.Sync(); err != nil {
+		return err
+	}
+	return summaryErr
+}
+
+// hashFields writes fields to h. Fields of primitive types are written by
+// their values, the others, like objects and errors, are added to a map whose
+// values are written in the format of fmt, so that the content of pointers is
+// hashed when the log is written.
+func hashFields(h *maphash.Hash, fields []zapcore.Field) {
+	for _, f := range fields {
+		writeString(h, f.Key)
+		_ = h.WriteByte(byte(f.Type))
+		if f.Interface == nil {
+			writeUint64(h, uint64(f.Integer))
+			writeString(h, f.String)
+			continue
+		}
+		writeEncoded(h, f)
+	}
+}
+
+// writeEncoded writes the values f adds to an encoder to w, in the format of
+// fmt and in the order of their keys.
+func writeEncoded(w io.Writer, f zapcore.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%q:%+v;", k, enc.Fields[k])
+	}
+}
+
+// fieldsEqual reports whether the fields are equal, the ones which are not of
+// primitive types by their encoded values, like in hashFields.
+func fieldsEqual(a, b []zapcore.Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		fa, fb := a[i], b[i]
+		if fa.Key != fb.Key || fa.Type != fb.Type || (fa.Interface == nil) != (fb.Interface == nil) {
+			return false
+		}
+		if fa.Interface == nil {
+			if fa.Integer != fb.Integer || fa.String != fb.String {
+				return false
+			}
+			continue
+		}
+		var ea, eb strings.Builder
+		writeEncoded(&ea, fa)
+		writeEncoded(&eb, fb)
+		if ea.String() != eb.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// writeString writes s to h, followed by its length so that adjacent strings
+// are not confused.
+func writeString(h *maphash.Hash, s string) {
+	_, _ = h.WriteString(s)
+	writeUint64(h, uint64(len(s)))
+}
+
+func writeUint64(h *maphash.Hash, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	_, _ = h.Write(b[:])
+}
@@ -0,0 +1,193 @@
+package zap
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func dedupConfig(name string, window time.Duration) xlog.Config {
+	return xlog.Config{{
+		Name:      name,
+		Writer:    testWriter,
+		Formatter: xlog.FormatterJSON,
+		Dedup:     xlog.DedupConfig{Window: window},
+	}}
+}
+
+// testMessages returns the messages written by the test output.
+func testMessages(t *testing.T, name string) []string {
+	t.Helper()
+	var msgs []string
+	for _, e := range testEntries(t, name) {
+		msgs = append(msgs, e["M"].(string))
+	}
+	return msgs
+}
+
+func TestDedupCollapsesConsecutiveDuplicates(t *testing.T) {
+	l := newTestLog(t, dedupConfig("dedup-out", time.Hour))
+	child := l.With("k", 1)
+	for i := 0; i < 4; i++ {
+		l.InfoFields("same", xlog.Int("n", 1))
+	}
+	l.InfoFields("same", xlog.Int("n", 2))
+	l.WarnFields("same", xlog.Int("n", 2))
+	child.WarnFields("same", xlog.Int("n", 2))
+	child.WarnFields("same", xlog.Int("n", 2))
+	l.Named("other").WarnFields("same", xlog.Int("n", 2))
+
+	want := []string{
+		"same",
+		"last message repeated 3 times",
+		// Logs with other fields, levels, context fields or logger names
+		// are not duplicates.
+		"same",
+		"same",
+		"same",
+		"last message repeated 1 times",
+		"same",
+	}
+	if got := testMessages(t, "dedup-out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestDedupHashesPointedContent(t *testing.T) {
+	type state struct{ N int }
+	l := newTestLog(t, dedupConfig("dedup-pointer-out", time.Hour))
+	s := &state{N: 1}
+	l.InfoFields("state", xlog.Any("s", s))
+	s.N = 2
+	l.InfoFields("state", xlog.Any("s", s))
+	l.InfoFields("state", xlog.Any("s", &state{N: 2}))
+
+	want := []string{"state", "state"}
+	if got := testMessages(t, "dedup-pointer-out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestDedupSummaryAfterWindow(t *testing.T) {
+	l := newTestLog(t, dedupConfig("dedup-window-out", 10*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		l.Warn("same")
+	}
+
+	want := []string{"same", "last message repeated 2 times"}
+	var got []string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if got = testMessages(t, "dedup-window-out"); len(got) >= len(want) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	if e := testEntries(t, "dedup-window-out")[1]; e["L"] != "WARN" {
+		t.Errorf("summary level = %v, want WARN", e["L"])
+	}
+}
+
+func TestDedupSummaryOnClose(t *testing.T) {
+	l := newTestLog(t, dedupConfig("dedup-close-out", time.Hour))
+	for i := 0; i < 3; i++ {
+		l.Info("same")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	l.Info("same")
+
+	want := []string{"same", "last message repeated 2 times"}
+	if got := testMessages(t, "dedup-close-out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestDedupSummaryOnReload(t *testing.T) {
+	l := newTestLog(t, dedupConfig("dedup-reload-out", time.Hour))
+	for i := 0; i < 3; i++ {
+		l.Info("same")
+	}
+	if _, err := l.Reload(context.Background(), dedupConfig("dedup-reload-out", time.Hour)); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	bufs := testOutput("dedup-reload-out")
+	replaced := bufs[len(bufs)-2]
+	if !replaced.isClosed() {
+		t.Fatal("replaced writer not closed")
+	}
+	lines := replaced.lines()
+	if len(lines) != 2 || !strings.Contains(lines[1], `"M":"last message repeated 2 times"`) {
+		t.Errorf("lines of the replaced writer = %q, want the log and its summary", lines)
+	}
+}
+
+func TestDedupKeyCollision(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	dc, d := newDedupCore(core, xlog.DedupConfig{Window: time.Hour})
+	t.Cleanup(func() { _ = d.stop() })
+	c := dc.(*dedupCore)
+
+	a := zapcore.Entry{Message: "a"}
+	b := zapcore.Entry{Message: "b"}
+	if err := c.Write(a, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// The last log gets the key of the next one, as if their keys collided.
+	d.last.key = c.key(b, nil)
+	if err := c.Write(b, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	fields := []zapcore.Field{{Key: "n", Type: zapcore.Int64Type, Integer: 2}}
+	d.last.key = c.key(b, fields)
+	if err := c.Write(b, fields); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if n := logs.Len(); n != 3 {
+		t.Errorf("written logs = %d, want 3", n)
+	}
+}
+
+func TestDedupSummaryFollowsRepeatedLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	dc, d := newDedupCore(core, xlog.DedupConfig{Window: time.Hour})
+	c := dc.(*dedupCore)
+
+	var wg sync.WaitGroup
+	for _, lvl := range []zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel} {
+		wg.Add(1)
+		go func(lvl zapcore.Level) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				_ = c.Write(zapcore.Entry{Level: lvl, Message: lvl.String()}, nil)
+			}
+		}(lvl)
+	}
+	wg.Wait()
+	if err := d.stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	// A summary has the level of the log it repeats, which is right before it.
+	entries := logs.AllUntimed()
+	for i, e := range entries {
+		if !strings.HasPrefix(e.Message, "last message repeated ") {
+			continue
+		}
+		if i == 0 || entries[i-1].Message != e.Level.String() {
+			t.Fatalf("summary %d %s %q does not follow its log", i, e.Level, e.Message)
+		}
+	}
+}
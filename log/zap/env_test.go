@@ -0,0 +1,96 @@
+package zap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	xlog "github.com/oyogames2023/zeus-log"
+)
+
+// appliedEnvOverrides returns the recorded overrides of the logger.
+func appliedEnvOverrides(logger string) []xlog.EnvOverride {
+	var overrides []xlog.EnvOverride
+	for _, o := range xlog.AppliedEnvOverrides() {
+		if o.Logger == logger {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides
+}
+
+func TestEnvOverrideUnknownFormat(t *testing.T) {
+	t.Setenv("ZEUS_LOG_FORMAT_ENV_FORMAT", "xml")
+	t.Setenv("ZEUS_LOG_LEVEL_ENV_FORMAT", "warn")
+	err := xlog.SetupFromBytes([]byte(`
+log:
+  env-format:
+    - name: env-format-out
+      writer: test
+`))
+	var ce *xlog.ConfigError
+	if !errors.As(err, &ce) || ce.Field != "formatter" || ce.Value != "xml" {
+		t.Fatalf("SetupFromBytes err = %v, want formatter ConfigError", err)
+	}
+	if want := `formatter: "xml": not registered in env ZEUS_LOG_FORMAT_ENV_FORMAT`; !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	l := xlog.Get("env-format").(*zapLog)
+	t.Cleanup(func() { _ = l.Close() })
+	c := l.state.current.Load().cfg[0]
+	if c.Formatter != "" || c.Level != "warn" {
+		t.Errorf("formatter = %q, level = %q, want only the level overridden", c.Formatter, c.Level)
+	}
+	overrides := appliedEnvOverrides("env-format")
+	if len(overrides) != 1 || overrides[0].Var != "ZEUS_LOG_LEVEL_ENV_FORMAT" {
+		t.Errorf("applied overrides = %v, want only the level", overrides)
+	}
+}
+
+func TestEnvOverrideNotRecordedOnFailure(t *testing.T) {
+	t.Setenv("ZEUS_LOG_LEVEL_ENV_FAIL", "warn")
+	err := xlog.SetupFromBytes([]byte(`
+log:
+  env-fail:
+    - writer: kafka
+`))
+	if err == nil {
+		t.Fatal("SetupFromBytes with unknown writer succeeded")
+	}
+	if overrides := appliedEnvOverrides("env-fail"); len(overrides) != 0 {
+		t.Errorf("applied overrides = %v, want none", overrides)
+	}
+}
+
+func TestEnvOverrideKeepsConfigAsWritten(t *testing.T) {
+	t.Setenv("ZEUS_LOG_LEVEL_ENV_SAME", "error")
+	doc := []byte(`
+log:
+  env-same:
+    - name: env-same-out
+      writer: test
+      level: info
+      sampling:
+        initial: 10
+`)
+	if err := xlog.SetupFromBytes(doc); err != nil {
+		t.Fatalf("SetupFromBytes: %v", err)
+	}
+	l := xlog.Get("env-same").(*zapLog)
+	t.Cleanup(func() { _ = l.Close() })
+	c := l.state.current.Load().cfg[0]
+	if c.Level != "error" || c.Sampling.Levels != nil {
+		t.Errorf("level = %q, sampling levels = %#v, want error and nil", c.Level, c.Sampling.Levels)
+	}
+	diffs, err := xlog.ReloadFromBytes(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("ReloadFromBytes: %v", err)
+	}
+	if d := diffs["env-same"]; !d.Empty() {
+		t.Errorf("diff = %v, want no change", d)
+	}
+	if overrides := appliedEnvOverrides("env-same"); len(overrides) != 1 {
+		t.Errorf("applied overrides = %v, want the level", overrides)
+	}
+}
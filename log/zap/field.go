@@ -0,0 +1,167 @@
+package zap
+
+import (
+	"fmt"
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"math"
+	"time"
+)
+
+// toZapFields converts user defined fields to zap fields.
+func toZapFields(fields []xlog.Field) []zap.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	zapFields := make([]zap.Field, len(fields))
+	for i := range fields {
+		zapFields[i] = toZapField(fields[i])
+	}
+	return zapFields
+}
+
+// toZapField converts a user defined field to zap field. Typed fields are mapped
+// to the native zapcore field types, so no reflection is involved.
+func toZapField(f xlog.Field) zap.Field {
+	switch f.Type {
+	case xlog.StringType:
+		return zap.String(f.Key, f.String)
+	case xlog.Int64Type:
+		return zap.Int64(f.Key, f.Integer)
+	case xlog.Uint64Type:
+		return zap.Uint64(f.Key, uint64(f.Integer))
+	case xlog.Float64Type:
+		return zap.Float64(f.Key, math.Float64frombits(uint64(f.Integer)))
+	case xlog.BoolType:
+		return zap.Bool(f.Key, f.Integer == 1)
+	case xlog.DurationType:
+		return zap.Duration(f.Key, time.Duration(f.Integer))
+	case xlog.TimeType:
+		return zapcore.Field{Key: f.Key, Type: zapcore.TimeType, Integer: f.Integer, Interface: f.Value}
+	case xlog.TimeFullType:
+		if t, ok := f.Value.(time.Time); ok {
+			return zap.Time(f.Key, t)
+		}
+	case xlog.ErrorType:
+		if err, ok := f.Value.(error); ok {
+			return zap.NamedError(f.Key, err)
+		}
+		return zap.Skip()
+	case xlog.StringerType:
+		if s, ok := f.Value.(fmt.Stringer); ok {
+			return zap.Stringer(f.Key, s)
+		}
+	case xlog.BinaryType:
+		if b, ok := f.Value.([]byte); ok {
+			return zap.Binary(f.Key, b)
+		}
+	case xlog.ObjectType:
+		if m, ok := f.Value.(xlog.ObjectMarshaler); ok {
+			return zap.Object(f.Key, objectMarshaler{m})
+		}
+	case xlog.ArrayType:
+		if m, ok := f.Value.(xlog.ArrayMarshaler); ok {
+			return zap.Array(f.Key, arrayMarshaler{m})
+		}
+	}
+	return anyToZapField(f.Key, f.Value)
+}
+
+// anyToZapField converts a value of arbitrary type to zap field. Values of
+// common types are mapped to the native zapcore field types, the others are
+// left to zap.Any.
+func anyToZapField(key string, value any) zap.Field {
+	switch v := value.(type) {
+	case string:
+		return zap.String(key, v)
+	case int:
+		return zap.Int(key, v)
+	case int64:
+		return zap.Int64(key, v)
+	case int32:
+		return zap.Int32(key, v)
+	case uint:
+		return zap.Uint(key, v)
+	case uint64:
+		return zap.Uint64(key, v)
+	case uint32:
+		return zap.Uint32(key, v)
+	case float64:
+		return zap.Float64(key, v)
+	case float32:
+		return zap.Float32(key, v)
+	case bool:
+		return zap.Bool(key, v)
+	case time.Duration:
+		return zap.Duration(key, v)
+	case time.Time:
+		return zap.Time(key, v)
+	case []byte:
+		return zap.Binary(key, v)
+	case error:
+		return zap.NamedError(key, v)
+	case xlog.ObjectMarshaler:
+		return zap.Object(key, objectMarshaler{v})
+	case xlog.ArrayMarshaler:
+		return zap.Array(key, arrayMarshaler{v})
+	case fmt.Stringer:
+		return zap.Stringer(key, v)
+	}
+	return zap.Any(key, value)
+}
+
+// objectMarshaler adapts xlog.ObjectMarshaler to zapcore.ObjectMarshaler.
+type objectMarshaler struct {
+	m xlog.ObjectMarshaler
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (o objectMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.m.MarshalLogObject(objectEncoder{enc})
+}
+
+// arrayMarshaler adapts xlog.ArrayMarshaler to zapcore.ArrayMarshaler.
+type arrayMarshaler struct {
+	m xlog.ArrayMarshaler
+}
+
+// MarshalLogArray implements zapcore.ArrayMarshaler.
+func (a arrayMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.m.MarshalLogArray(arrayEncoder{enc})
+}
+
+// objectEncoder adapts zapcore.ObjectEncoder to xlog.ObjectEncoder.
+type objectEncoder struct {
+	zapcore.ObjectEncoder
+}
+
+// AddObject implements xlog.ObjectEncoder.
+func (e objectEncoder) AddObject(key string, m xlog.ObjectMarshaler) error {
+	return e.ObjectEncoder.AddObject(key, objectMarshaler{m})
+}
+
+// AddArray implements xlog.ObjectEncoder.
+func (e objectEncoder) AddArray(key string, m xlog.ArrayMarshaler) error {
+	return e.ObjectEncoder.AddArray(key, arrayMarshaler{m})
+}
+
+// arrayEncoder adapts zapcore.ArrayEncoder to xlog.ArrayEncoder.
+type arrayEncoder struct {
+	zapcore.ArrayEncoder
+}
+
+// AppendObject implements xlog.ArrayEncoder.
+func (e arrayEncoder) AppendObject(m xlog.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(objectMarshaler{m})
+}
+
+// AppendArray implements xlog.ArrayEncoder.
+func (e arrayEncoder) AppendArray(m xlog.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(arrayMarshaler{m})
+}
+
+var (
+	_ xlog.ObjectEncoder = objectEncoder{}
+	_ xlog.ArrayEncoder  = arrayEncoder{}
+)
@@ -0,0 +1,164 @@
+package zap
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testObject struct{ id int64 }
+
+func (o testObject) MarshalLogObject(enc xlog.ObjectEncoder) error {
+	enc.AddInt64("id", o.id)
+	return nil
+}
+
+func TestToZapFieldNativeTypes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 1, time.UTC)
+	far := time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := xlog.ArrayMarshalerFunc(func(enc xlog.ArrayEncoder) error {
+		enc.AppendInt64(1)
+		enc.AppendString("two")
+		return enc.AppendObject(testObject{id: 3})
+	})
+	tests := []struct {
+		name  string
+		field xlog.Field
+		want  zapcore.FieldType
+		// value is the value encoded, nil if the field is skipped.
+		value any
+	}{
+		{name: "string", field: xlog.String("k", "v"), want: zapcore.StringType, value: "v"},
+		{name: "int", field: xlog.Int("k", -2), want: zapcore.Int64Type, value: int64(-2)},
+		{name: "int64", field: xlog.Int64("k", -1), want: zapcore.Int64Type, value: int64(-1)},
+		{name: "uint64", field: xlog.Uint64("k", math.MaxUint64), want: zapcore.Uint64Type, value: uint64(math.MaxUint64)},
+		{name: "float64", field: xlog.Float64("k", 1.5), want: zapcore.Float64Type, value: 1.5},
+		{name: "bool", field: xlog.Bool("k", true), want: zapcore.BoolType, value: true},
+		{name: "duration", field: xlog.Duration("k", time.Second), want: zapcore.DurationType, value: time.Second},
+		{name: "time", field: xlog.Time("k", now), want: zapcore.TimeType, value: now},
+		{name: "time full", field: xlog.Time("k", far), want: zapcore.TimeFullType, value: far},
+		{name: "error", field: xlog.NamedErr("k", errors.New("e")), want: zapcore.ErrorType, value: "e"},
+		{name: "nil error", field: xlog.Err(nil), want: zapcore.SkipType},
+		{name: "stringer", field: xlog.Stringer("k", time.Second), want: zapcore.StringerType, value: "1s"},
+		{name: "binary", field: xlog.Binary("k", []byte{1}), want: zapcore.BinaryType, value: []byte{1}},
+		{name: "object", field: xlog.Object("k", testObject{id: 1}), want: zapcore.ObjectMarshalerType,
+			value: map[string]any{"id": int64(1)}},
+		{name: "array", field: xlog.Array("k", ids), want: zapcore.ArrayMarshalerType,
+			value: []any{int64(1), "two", map[string]any{"id": int64(3)}}},
+		{name: "untyped", field: xlog.Field{Key: "k", Value: struct{ A int }{1}}, want: zapcore.ReflectType,
+			value: struct{ A int }{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := toZapField(tt.field)
+			if f.Type != tt.want {
+				t.Errorf("type = %v, want %v", f.Type, tt.want)
+			}
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			got, ok := enc.Fields["k"]
+			if tt.value == nil {
+				if len(enc.Fields) != 0 {
+					t.Errorf("encoded = %v, want nothing", enc.Fields)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("encoded = %v, want key k", enc.Fields)
+			}
+			if want, isTime := tt.value.(time.Time); isTime {
+				if gt, _ := got.(time.Time); !gt.Equal(want) {
+					t.Errorf("encoded = %#v, want %v", got, want)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("encoded = %#v (%T), want %#v (%T)", got, got, tt.value, tt.value)
+			}
+		})
+	}
+}
+
+func TestArgsToZapFieldsNativeTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  zapcore.FieldType
+	}{
+		{name: "string", value: "v", want: zapcore.StringType},
+		{name: "int", value: 1, want: zapcore.Int64Type},
+		{name: "int32", value: int32(1), want: zapcore.Int32Type},
+		{name: "uint", value: uint(1), want: zapcore.Uint64Type},
+		{name: "float64", value: 1.5, want: zapcore.Float64Type},
+		{name: "bool", value: true, want: zapcore.BoolType},
+		{name: "duration", value: time.Second, want: zapcore.DurationType},
+		{name: "time", value: time.Now(), want: zapcore.TimeType},
+		{name: "binary", value: []byte{1}, want: zapcore.BinaryType},
+		{name: "error", value: errors.New("e"), want: zapcore.ErrorType},
+		{name: "object", value: testObject{id: 1}, want: zapcore.ObjectMarshalerType},
+		{name: "stringer", value: net.IPv4(127, 0, 0, 1), want: zapcore.StringerType},
+		{name: "struct", value: struct{ A int }{1}, want: zapcore.ReflectType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := argsToZapFields([]any{"k", tt.value})
+			if len(fields) != 1 || fields[0].Key != "k" || fields[0].Type != tt.want {
+				t.Errorf("fields = %+v, want one field k of type %v", fields, tt.want)
+			}
+			if got := toZapField(xlog.Any("k", tt.value)).Type; got != tt.want {
+				t.Errorf("xlog.Any type = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newBenchmarkLog() *zapLog {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	core := zapcore.NewCore(enc, zapcore.AddSync(io.Discard), zapcore.DebugLevel)
+	return &zapLog{logger: zap.New(core)}
+}
+
+func BenchmarkInfoFieldsTyped(b *testing.B) {
+	l := newBenchmarkLog()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.InfoFields("tick",
+			xlog.Int64("frame", int64(i)),
+			xlog.String("room", "lobby"),
+			xlog.Float64("dt", 0.016),
+			xlog.Bool("ok", true),
+		)
+	}
+}
+
+func BenchmarkInfoFieldsAny(b *testing.B) {
+	l := newBenchmarkLog()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.InfoFields("tick",
+			xlog.Field{Key: "frame", Value: int64(i)},
+			xlog.Field{Key: "room", Value: "lobby"},
+			xlog.Field{Key: "dt", Value: 0.016},
+			xlog.Field{Key: "ok", Value: true},
+		)
+	}
+}
+
+func BenchmarkWithArgs(b *testing.B) {
+	l := newBenchmarkLog()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.With("frame", i, "room", "lobby", "dt", 0.016, "ok", true)
+	}
+}
@@ -0,0 +1,88 @@
+package zap
+
+import (
+	"fmt"
+	xlog "github.com/oyogames2023/zeus-log"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+	"github.com/oyogames2023/zeus-log/plugin"
+	"go.uber.org/zap/zapcore"
+)
+
+// FormatterDecoder decodes the format config for formatters, which set the
+// Encoder of it in Setup. Stack traces are set to the Stack of entries in the
+// format of zap, formatters may encode them otherwise, like the json formatter
+// does by the stack trace format.
+type FormatterDecoder struct {
+	OutputConfig *xlog.OutputConfig
+	// EncoderConfig is built from the format config, with the keys and the
+	// encoders of levels, times and callers set.
+	EncoderConfig zapcore.EncoderConfig
+	Encoder       zapcore.Encoder
+}
+
+// Decode decodes formatter configuration. A **FormatConfig gets the format
+// config of the output, any other cfg is decoded from the options block of
+// the format config strictly, see plugin.YAMLNodeDecoder.
+func (d *FormatterDecoder) Decode(cfg interface{}) error {
+	if fc, ok := cfg.(**xlog.FormatConfig); ok {
+		*fc = &d.OutputConfig.FormatConfig
+		return nil
+	}
+	if err := plugin.NewYAMLNodeDecoder(&d.OutputConfig.FormatConfig.Options).Decode(cfg); err != nil {
+		return fmt.Errorf("decode format_config.options: %w", err)
+	}
+	return nil
+}
+
+// ConsoleFormatterFactory is the console formatter instance.
+type ConsoleFormatterFactory struct {
+}
+
+// Type returns the log plugin type.
+func (f *ConsoleFormatterFactory) Type() string {
+	return pluginType
+}
+
+// Setup creates the console encoder.
+func (f *ConsoleFormatterFactory) Setup(name string, dec plugin.Decoder) error {
+	decoder, err := formatterDecoder(dec)
+	if err != nil {
+		return err
+	}
+	decoder.Encoder = zapcore.NewConsoleEncoder(decoder.EncoderConfig)
+	return nil
+}
+
+// JSONFormatterFactory is the json formatter instance.
+type JSONFormatterFactory struct {
+}
+
+// Type returns the log plugin type.
+func (f *JSONFormatterFactory) Type() string {
+	return pluginType
+}
+
+// Setup creates the json encoder, which encodes stack traces as arrays of
+// frames if the stack trace format is array.
+func (f *JSONFormatterFactory) Setup(name string, dec plugin.Decoder) error {
+	decoder, err := formatterDecoder(dec)
+	if err != nil {
+		return err
+	}
+	decoder.Encoder = zapcore.NewJSONEncoder(decoder.EncoderConfig)
+	if decoder.OutputConfig.FormatConfig.StacktraceFormat == xlog.StacktraceFormatArray {
+		decoder.Encoder = &stackArrayEncoder{
+			Encoder: decoder.Encoder,
+			key:     decoder.EncoderConfig.StacktraceKey,
+		}
+	}
+	return nil
+}
+
+func formatterDecoder(dec plugin.Decoder) (*FormatterDecoder, error) {
+	decoder, ok := dec.(*FormatterDecoder)
+	if !ok || decoder == nil {
+		return nil, ec.ErrInvalidFormatterDecoder
+	}
+	return decoder, nil
+}
@@ -0,0 +1,140 @@
+package zap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+	"github.com/oyogames2023/zeus-log/plugin"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// keysFormatter is a json formatter whose message key is set by its
+	// options.
+	keysFormatter = "test-keys"
+	// noEncoderFormatter is a formatter whose setup sets no encoder.
+	noEncoderFormatter = "test-no-encoder"
+)
+
+func init() {
+	xlog.RegisterFormatter(keysFormatter, &keysFormatterFactory{})
+	xlog.RegisterFormatter(noEncoderFormatter, &noEncoderFormatterFactory{})
+}
+
+type keysFormatterFactory struct{}
+
+func (f *keysFormatterFactory) Type() string {
+	return pluginType
+}
+
+func (f *keysFormatterFactory) Setup(name string, dec plugin.Decoder) error {
+	var fc *xlog.FormatConfig
+	if err := dec.Decode(&fc); err != nil {
+		return err
+	}
+	var opts struct {
+		MessageKey string `yaml:"message_key"`
+	}
+	if err := dec.Decode(&opts); err != nil {
+		return err
+	}
+	decoder := dec.(*FormatterDecoder)
+	cfg := decoder.EncoderConfig
+	cfg.MessageKey = opts.MessageKey
+	// The format config is the one of the output.
+	cfg.TimeKey = GetLogEncoderKey(zapcore.OmitKey, fc.TimeKey)
+	decoder.Encoder = zapcore.NewJSONEncoder(cfg)
+	return nil
+}
+
+type noEncoderFormatterFactory struct{}
+
+func (f *noEncoderFormatterFactory) Type() string {
+	return pluginType
+}
+
+func (f *noEncoderFormatterFactory) Setup(string, plugin.Decoder) error {
+	return nil
+}
+
+func TestCustomFormatter(t *testing.T) {
+	err := xlog.SetupFromBytes([]byte(`
+log:
+  custom-formatter:
+    - name: custom-formatter-out
+      writer: test
+      formatter: test-keys
+      format_config:
+        time_key: time
+        options:
+          message_key: message
+`))
+	if err != nil {
+		t.Fatalf("SetupFromBytes: %v", err)
+	}
+	l := xlog.Get("custom-formatter")
+	t.Cleanup(func() { _ = l.Close() })
+	l.Info("hello")
+
+	e := testEntries(t, "custom-formatter-out")[0]
+	if e["message"] != "hello" {
+		t.Errorf("entry = %v, want the message under the key of the options", e)
+	}
+	if _, ok := e["time"]; !ok {
+		t.Errorf("entry = %v, want the time under the key of the format config", e)
+	}
+}
+
+func TestCustomFormatterUnknownOption(t *testing.T) {
+	err := xlog.SetupFromBytes([]byte(`
+log:
+  custom-formatter-typo:
+    - writer: test
+      formatter: test-keys
+      format_config:
+        options:
+          mesage_key: message
+`))
+	if err == nil {
+		t.Fatal("SetupFromBytes succeeded, want error")
+	}
+	for _, want := range []string{
+		"formatter test-keys setup fail: decode format_config.options:",
+		"line 8: field mesage_key not found",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestFormatterWithoutEncoder(t *testing.T) {
+	_, err := NewZapLogE(xlog.Config{{Writer: testWriter, Formatter: noEncoderFormatter}})
+	if err == nil || !strings.Contains(err.Error(), "formatter test-no-encoder setup no encoder") {
+		t.Errorf("err = %v, want setup no encoder", err)
+	}
+}
+
+func TestUnknownFormatter(t *testing.T) {
+	_, err := NewZapLogE(xlog.Config{{Writer: testWriter, Formatter: "xml"}})
+	var ce *xlog.ConfigError
+	if !errors.As(err, &ce) || ce.Field != "formatter" {
+		t.Fatalf("err = %v, want formatter ConfigError", err)
+	}
+	for _, name := range []string{xlog.FormatterConsole, xlog.FormatterJSON, keysFormatter} {
+		if !strings.Contains(ce.Error(), name) {
+			t.Errorf("err = %v, want %s allowed", ce, name)
+		}
+	}
+}
+
+func TestFormatterInvalidDecoder(t *testing.T) {
+	for _, f := range []plugin.Factory{&ConsoleFormatterFactory{}, &JSONFormatterFactory{}} {
+		if err := f.Setup("any", &Decoder{}); !errors.Is(err, ec.ErrInvalidFormatterDecoder) {
+			t.Errorf("%T Setup err = %v, want ErrInvalidFormatterDecoder", f, err)
+		}
+	}
+}
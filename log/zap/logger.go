@@ -1,12 +1,20 @@
 package zap
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/hashicorp/go-multierror"
 	xlog "github.com/oyogames2023/zeus-log"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
 	"github.com/oyogames2023/zeus-log/rollwriter"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"io"
+	"log"
+	"log/slog"
 	"os"
+	"runtime"
 	"strconv"
 	"time"
 )
@@ -17,6 +25,19 @@ const (
 	FileZapCore    = "file"
 )
 
+// TraceLevel is the zap level of trace logs. It's one below zapcore.DebugLevel,
+// so trace logs can be filtered out separately from debug logs.
+const TraceLevel = zapcore.DebugLevel - 1
+
+// OffLevel is the zap level which disables an output, it's above all levels.
+const OffLevel = zapcore.FatalLevel + 1
+
+// Encoded names of TraceLevel.
+const (
+	traceLevelCapitalString      = "TRACE"
+	traceLevelCapitalColorString = "\x1b[36mTRACE\x1b[0m"
+)
+
 var (
 	defaultConfig = []xlog.OutputConfig{
 		{
@@ -27,30 +48,34 @@ var (
 	}
 	Levels = map[string]zapcore.Level{
 		"":      zapcore.DebugLevel,
-		"trace": zapcore.DebugLevel,
+		"trace": TraceLevel,
 		"debug": zapcore.DebugLevel,
 		"info":  zapcore.InfoLevel,
 		"warn":  zapcore.WarnLevel,
 		"error": zapcore.ErrorLevel,
 		"fatal": zapcore.FatalLevel,
 		"panic": zapcore.PanicLevel,
+		"off":   OffLevel,
 	}
 	levelToZapLevel = map[xlog.Level]zapcore.Level{
-		xlog.LevelTrace: zapcore.DebugLevel,
+		xlog.LevelTrace: TraceLevel,
 		xlog.LevelDebug: zapcore.DebugLevel,
 		xlog.LevelInfo:  zapcore.InfoLevel,
 		xlog.LevelWarn:  zapcore.WarnLevel,
 		xlog.LevelError: zapcore.ErrorLevel,
 		xlog.LevelFatal: zapcore.FatalLevel,
 		xlog.LevelPanic: zapcore.PanicLevel,
+		xlog.LevelOff:   OffLevel,
 	}
 	zapLevelToLevel = map[zapcore.Level]xlog.Level{
+		TraceLevel:         xlog.LevelTrace,
 		zapcore.DebugLevel: xlog.LevelDebug,
 		zapcore.InfoLevel:  xlog.LevelInfo,
 		zapcore.WarnLevel:  xlog.LevelWarn,
 		zapcore.ErrorLevel: xlog.LevelError,
 		zapcore.FatalLevel: xlog.LevelFatal,
 		zapcore.PanicLevel: xlog.LevelPanic,
+		OffLevel:           xlog.LevelOff,
 	}
 )
 
@@ -59,35 +84,45 @@ func NewZapLog(c xlog.Config) xlog.Logger {
 	return NewZapLogWithCallerSkip(c, 2)
 }
 
-// NewZapLogWithCallerSkip creates a default Logger from zap.
+// NewZapLogWithCallerSkip creates a default Logger from zap. It panics if the
+// config is invalid or any writer fails to set up, use NewZapLogE to get the error.
 func NewZapLogWithCallerSkip(cfg xlog.Config, callerSkip int) xlog.Logger {
-	var (
-		cores  []zapcore.Core
-		levels []zap.AtomicLevel
-	)
-	for _, c := range cfg {
-		writer := xlog.GetWriter(c.Writer)
-		if writer == nil {
-			panic("log: writer core: " + c.Writer + " no registered")
-		}
-		decoder := &Decoder{OutputConfig: &c}
-		if err := writer.Setup(c.Writer, decoder); err != nil {
-			panic("log: writer core: " + c.Writer + " setup fail: " + err.Error())
-		}
-		cores = append(cores, decoder.Core)
-		levels = append(levels, decoder.ZapLevel)
+	logger, err := newZapLog(cfg, callerSkip)
+	if err != nil {
+		panic(err.Error())
+	}
+	return logger
+}
+
+// NewZapLogE creates a zap Logger object whose caller skip is set to 2. The config
+// is validated first, and an error is returned instead of panic on bad config.
+func NewZapLogE(c xlog.Config) (xlog.Logger, error) {
+	logger, err := newZapLog(c, 2)
+	if err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+func newZapLog(cfg xlog.Config, callerSkip int) (*zapLog, error) {
+	st, err := newLoggerState(cfg)
+	if err != nil {
+		return nil, err
 	}
+	holder := &stateHolder{}
+	holder.current.Store(st)
 	return &zapLog{
-		levels: levels,
+		state: holder,
 		logger: zap.New(
-			zapcore.NewTee(cores...),
+			&reloadableCore{holder: holder},
 			zap.AddCallerSkip(callerSkip),
 			zap.AddCaller(),
+			zap.WithFatalHook(fatalHook{}),
 		),
-	}
+	}, nil
 }
 
-func newEncoder(c *xlog.OutputConfig) zapcore.Encoder {
+func newEncoder(c *xlog.OutputConfig) (zapcore.Encoder, error) {
 	encoderCfg := zapcore.EncoderConfig{
 		TimeKey:        GetLogEncoderKey("T", c.FormatConfig.TimeKey),
 		LevelKey:       GetLogEncoderKey("L", c.FormatConfig.LevelKey),
@@ -97,22 +132,80 @@ func newEncoder(c *xlog.OutputConfig) zapcore.Encoder {
 		MessageKey:     GetLogEncoderKey("M", c.FormatConfig.MessageKey),
 		StacktraceKey:  GetLogEncoderKey("S", c.FormatConfig.StacktraceKey),
 		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeLevel:    CapitalLevelEncoder,
 		EncodeTime:     NewTimeEncoder(c.FormatConfig.TimeFormat),
 		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeCaller:   callerEncoderFor(c.FormatConfig.CallerFormat),
 	}
 	if c.EnableColor {
-		encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoderCfg.EncodeLevel = CapitalColorLevelEncoder
+	}
+	if c.FormatConfig.CallerFormat == xlog.CallerFormatNone {
+		encoderCfg.CallerKey = zapcore.OmitKey
+	}
+	if c.FormatConfig.FunctionFormat != "" {
+		encoderCfg.FunctionKey = GetLogEncoderKey("F", c.FormatConfig.FunctionKey)
+	}
+	name := c.Formatter
+	if name == "" {
+		name = xlog.FormatterConsole
+	}
+	formatter := xlog.GetFormatter(name)
+	if formatter == nil {
+		return nil, fmt.Errorf("formatter %s not registered", name)
+	}
+	decoder := &FormatterDecoder{OutputConfig: c, EncoderConfig: encoderCfg}
+	if err := formatter.Setup(name, decoder); err != nil {
+		return nil, fmt.Errorf("formatter %s setup fail: %w", name, err)
+	}
+	if decoder.Encoder == nil {
+		return nil, fmt.Errorf("formatter %s setup no encoder", name)
+	}
+	encoder := decoder.Encoder
+	moduleRelative := c.FormatConfig.CallerFormat == xlog.CallerFormatModuleRelative
+	shortFunction := c.FormatConfig.FunctionFormat == xlog.FunctionFormatPackage ||
+		c.FormatConfig.FunctionFormat == xlog.FunctionFormatMethod
+	if moduleRelative || shortFunction {
+		encoder = &callerFormatEncoder{
+			Encoder:        encoder,
+			moduleRelative: moduleRelative,
+			functionFormat: c.FormatConfig.FunctionFormat,
+		}
 	}
-	switch c.Formatter {
-	case "console":
-		return zapcore.NewConsoleEncoder(encoderCfg)
-	case "json":
-		return zapcore.NewJSONEncoder(encoderCfg)
-	default:
-		return zapcore.NewConsoleEncoder(encoderCfg)
+	return encoder, nil
+}
+
+// CapitalLevelEncoder serializes a Level to an all-caps string, it knows
+// TraceLevel in addition to zapcore.CapitalLevelEncoder.
+func CapitalLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == TraceLevel {
+		enc.AppendString(traceLevelCapitalString)
+		return
 	}
+	zapcore.CapitalLevelEncoder(l, enc)
+}
+
+// CapitalColorLevelEncoder serializes a Level to an all-caps string and adds
+// color, it knows TraceLevel in addition to zapcore.CapitalColorLevelEncoder.
+func CapitalColorLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == TraceLevel {
+		enc.AppendString(traceLevelCapitalColorString)
+		return
+	}
+	zapcore.CapitalColorLevelEncoder(l, enc)
+}
+
+// getZapLevel parses the configured level name case-insensitively, an empty
+// or unknown name is taken as debug.
+func getZapLevel(level string) zapcore.Level {
+	if level == "" {
+		return zapcore.DebugLevel
+	}
+	lv, err := xlog.ParseLevel(level)
+	if err != nil {
+		return zapcore.DebugLevel
+	}
+	return levelToZapLevel[lv]
 }
 
 // GetLogEncoderKey gets user defined log output name, uses defKey if empty.
@@ -123,21 +216,28 @@ func GetLogEncoderKey(defKey, key string) string {
 	return key
 }
 
-func newConsoleCore(c *xlog.OutputConfig) (zapcore.Core, zap.AtomicLevel) {
-	lvl := zap.NewAtomicLevelAt(Levels[c.Level])
-	return zapcore.NewCore(
-		newEncoder(c),
-		zapcore.Lock(os.Stdout),
-		lvl), lvl
+// NewCore creates the core of an output writing to ws, with the formatter and
+// level of the output config. Third-party writers use it to build the Core of
+// their Decoder.
+func NewCore(c *xlog.OutputConfig, ws zapcore.WriteSyncer) (zapcore.Core, zap.AtomicLevel, error) {
+	encoder, err := newEncoder(c)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+	lvl := zap.NewAtomicLevelAt(getZapLevel(c.Level))
+	return zapcore.NewCore(encoder, ws, lvl), lvl, nil
+}
+
+func newConsoleCore(c *xlog.OutputConfig) (zapcore.Core, zap.AtomicLevel, error) {
+	return NewCore(c, zapcore.Lock(os.Stdout))
 }
 
-func newFileCore(c *xlog.OutputConfig) (zapcore.Core, zap.AtomicLevel, error) {
-	fmt.Println(c.WriterConfig.MaxAge)
+func newFileCore(c *xlog.OutputConfig) (zapcore.Core, zap.AtomicLevel, io.Closer, error) {
 	opts := []rollwriter.Option{
-		rollwriter.WithMaxAge(c.WriterConfig.MaxAge),
+		rollwriter.WithMaxAge(int(c.WriterConfig.MaxAge)),
 		rollwriter.WithMaxBackups(c.WriterConfig.MaxBackups),
 		rollwriter.WithCompress(c.WriterConfig.Compress),
-		rollwriter.WithMaxSize(c.WriterConfig.MaxSize),
+		rollwriter.WithMaxSize(int(c.WriterConfig.MaxSize)),
 	}
 	// roll by time.
 	if c.WriterConfig.RollType != xlog.RollingBySizeStr {
@@ -145,32 +245,37 @@ func newFileCore(c *xlog.OutputConfig) (zapcore.Core, zap.AtomicLevel, error) {
 	}
 	writer, err := rollwriter.NewRollWriter(c.WriterConfig.FileName, opts...)
 	if err != nil {
-		return nil, zap.AtomicLevel{}, err
+		return nil, zap.AtomicLevel{}, nil, err
 	}
 
 	// write mode.
-	var ws zapcore.WriteSyncer
+	var (
+		ws     zapcore.WriteSyncer
+		closer io.Closer
+	)
 	switch m := xlog.GetWriteMode(c.WriterConfig.WriteMode); m {
 	case 0, xlog.WriteFast:
 		// Use WriteFast as default mode.
 		// It has better performance, discards logs on full and avoid blocking service.
-		ws = rollwriter.NewAsyncRollWriter(writer, rollwriter.WithDropLog(true))
+		w := rollwriter.NewAsyncRollWriter(writer, rollwriter.WithDropLog(true))
+		ws, closer = w, w
 	case xlog.WriteSync:
-		ws = zapcore.AddSync(writer)
+		ws, closer = zapcore.AddSync(writer), writer
 	case xlog.WriteAsync:
-		ws = rollwriter.NewAsyncRollWriter(writer, rollwriter.WithDropLog(false))
+		w := rollwriter.NewAsyncRollWriter(writer, rollwriter.WithDropLog(false))
+		ws, closer = w, w
 	default:
-		return nil, zap.AtomicLevel{}, fmt.Errorf("validating WriteMode parameter: got %d, "+
+		return nil, zap.AtomicLevel{}, nil, fmt.Errorf("validating WriteMode parameter: got %d, "+
 			"but expect one of WriteFast(%d), WriteAsync(%d), or WriteSync(%d)", m,
 			xlog.WriteFast, xlog.WriteAsync, xlog.WriteSync)
 	}
 
-	// log level.
-	lvl := zap.NewAtomicLevelAt(Levels[c.Level])
-	return zapcore.NewCore(
-		newEncoder(c),
-		ws, lvl,
-	), lvl, nil
+	core, lvl, err := NewCore(c, ws)
+	if err != nil {
+		_ = closer.Close()
+		return nil, zap.AtomicLevel{}, nil, err
+	}
+	return core, lvl, closer, nil
 }
 
 // NewTimeEncoder creates a time format encoder.
@@ -230,8 +335,18 @@ func defaultTimeFormat(t time.Time) []byte {
 
 // zapLog is a Logger implementation based on zaplogger.
 type zapLog struct {
-	levels []zap.AtomicLevel
+	// state is shared by l and all loggers derived from it.
+	state  *stateHolder
 	logger *zap.Logger
+	// name is the full dotted name of the logger.
+	name string
+}
+
+// withLogger returns a copy of l which logs to logger.
+func (l *zapLog) withLogger(logger *zap.Logger) *zapLog {
+	c := *l
+	c.logger = logger
+	return &c
 }
 
 func (l *zapLog) WithOptions(opts ...xlog.Option) xlog.Logger {
@@ -239,27 +354,85 @@ func (l *zapLog) WithOptions(opts ...xlog.Option) xlog.Logger {
 	for _, opt := range opts {
 		opt(o)
 	}
-	return &zapLog{
-		levels: l.levels,
-		logger: l.logger.WithOptions(zap.AddCallerSkip(o.Skip)),
+	zapOpts := []zap.Option{zap.AddCallerSkip(o.Skip)}
+	if o.StacktraceLevel != xlog.LevelOff {
+		level := levelToZapLevel[o.StacktraceLevel]
+		zapOpts = append(zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &stackLevelCore{Core: core, level: level}
+		}))
 	}
+	return l.withLogger(l.logger.WithOptions(zapOpts...))
 }
 
-// With returns a new logger with key/value paris.
+// With returns a new logger with key/value pairs. See argsToZapFields for how
+// malformed arguments are handled.
 func (l *zapLog) With(args ...any) xlog.Logger {
-	return nil
+	return l.withLogger(l.logger.With(argsToZapFields(args)...))
 }
 
 // WithFields returns a new logger with key/value paris.
 func (l *zapLog) WithFields(fields ...xlog.Field) xlog.Logger {
-	zapFields := make([]zap.Field, len(fields))
-	for i := range fields {
-		zapFields[i] = zap.Any(fields[i].Key, fields[i].Value)
+	return l.withLogger(l.logger.With(toZapFields(fields)...))
+}
+
+// Enabled reports whether logs at level are printed by any output.
+func (l *zapLog) Enabled(level xlog.Level) bool {
+	lvl, ok := levelToZapLevel[level]
+	if !ok || level == xlog.LevelOff || (level == xlog.LevelTrace && !xlog.IsTraceEnabled(l.name)) {
+		return false
+	}
+	return l.logger.Core().Enabled(lvl)
+}
+
+// Named returns a new logger with name appended to the name of l, separated by
+// a period.
+func (l *zapLog) Named(name string) xlog.Logger {
+	if name == "" {
+		return l
+	}
+	c := l.withLogger(l.logger.Named(name))
+	if l.name == "" {
+		c.name = name
+	} else {
+		c.name = l.name + "." + name
+	}
+	return c
+}
+
+// badKey is the key used for arguments of With which can not be paired.
+const badKey = "!BADKEY"
+
+// argsToZapFields converts loosely typed key/value pairs to zap fields.
+//
+// A string argument is treated as a key and paired with the argument that
+// follows it, whose type is mapped to the native field type by anyToZapField.
+// An xlog.Field or zap.Field argument is used as a complete field on its own.
+// A trailing key without a value and a key which is neither a string nor a
+// field are kept under badKey, so malformed calls never lose data.
+func argsToZapFields(args []any) []zap.Field {
+	fields := make([]zap.Field, 0, (len(args)+1)/2)
+	for i := 0; i < len(args); {
+		switch arg := args[i].(type) {
+		case xlog.Field:
+			fields = append(fields, toZapField(arg))
+			i++
+		case zap.Field:
+			fields = append(fields, arg)
+			i++
+		case string:
+			if i+1 == len(args) {
+				fields = append(fields, zap.String(badKey, arg))
+				i++
+				continue
+			}
+			fields = append(fields, anyToZapField(arg, args[i+1]))
+			i += 2
+		default:
+			fields = append(fields, zap.Any(badKey, arg))
+			i++
+		}
 	}
-
-	return &zapLog{
-		levels: l.levels,
-		logger: l.logger.With(zapFields...)}
+	return fields
 }
 
 func getLogMsg(args ...interface{}) string {
@@ -274,22 +447,22 @@ func getLogMsgf(format string, args ...interface{}) string {
 
 // Trace logs to TRACE log. Arguments are handled in the manner of fmt.Print.
 func (l *zapLog) Trace(args ...any) {
-	if l.logger.Core().Enabled(zapcore.DebugLevel) {
-		l.logger.Debug(getLogMsg(args...))
+	if xlog.IsTraceEnabled(l.name) && l.logger.Core().Enabled(TraceLevel) {
+		l.logger.Log(TraceLevel, getLogMsg(args...))
 	}
 }
 
 // Tracef logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Tracef(format string, args ...any) {
-	if l.logger.Core().Enabled(zapcore.DebugLevel) {
-		l.logger.Debug(getLogMsgf(format, args...))
+	if xlog.IsTraceEnabled(l.name) && l.logger.Core().Enabled(TraceLevel) {
+		l.logger.Log(TraceLevel, getLogMsgf(format, args...))
 	}
 }
 
 // Traceln logs to TRACE log. Arguments are handled in the manner of fmt.Println.
 func (l *zapLog) Traceln(args ...any) {
-	if l.logger.Core().Enabled(zapcore.DebugLevel) {
-		l.logger.Debug(fmt.Sprintf("%s\n", getLogMsg(args...)))
+	if xlog.IsTraceEnabled(l.name) && l.logger.Core().Enabled(TraceLevel) {
+		l.logger.Log(TraceLevel, fmt.Sprintf("%s\n", getLogMsg(args...)))
 	}
 }
 
@@ -377,45 +550,155 @@ func (l *zapLog) Errorln(args ...any) {
 	}
 }
 
+// fatalHook terminates the process by xlog.Terminate after fatal logs are
+// written, so that exit hooks run and the exit function can be replaced.
+type fatalHook struct{}
+
+// OnWrite implements zapcore.CheckWriteHook.
+func (fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	xlog.Terminate(1)
+}
+
+// fatalFields returns the fields added to fatal logs, which is the goroutine
+// dump if it's enabled by xlog.SetFatalStackDump.
+func fatalFields() []zapcore.Field {
+	if !xlog.FatalStackDumpEnabled() {
+		return nil
+	}
+	return []zapcore.Field{zap.ByteString(xlog.GoroutinesKey, xlog.GoroutineDump())}
+}
+
 // Fatal logs to FATAL log. Arguments are handled in the manner of fmt.Print.
 func (l *zapLog) Fatal(args ...any) {
-	if l.logger.Core().Enabled(zapcore.FatalLevel) {
-		l.logger.Fatal(getLogMsg(args...))
-	}
+	l.logger.Fatal(getLogMsg(args...), fatalFields()...)
 }
 
 // Fatalf logs to FATAL log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Fatalf(format string, args ...any) {
-	if l.logger.Core().Enabled(zapcore.FatalLevel) {
-		l.logger.Fatal(getLogMsgf(format, args...))
-	}
+	l.logger.Fatal(getLogMsgf(format, args...), fatalFields()...)
 }
 
 // Fatalln logs to INFO log. Arguments are handled in the manner of fmt.Println.
 func (l *zapLog) Fatalln(args ...any) {
-	if l.logger.Core().Enabled(zapcore.FatalLevel) {
-		l.logger.Fatal(fmt.Sprintf("%s\n", getLogMsg(args...)))
-	}
+	l.logger.Fatal(fmt.Sprintf("%s\n", getLogMsg(args...)), fatalFields()...)
 }
 
 // Panic logs to FATAL log. Arguments are handled in the manner of fmt.Print.
 func (l *zapLog) Panic(args ...any) {
-	if l.logger.Core().Enabled(zapcore.PanicLevel) {
-		l.logger.Panic(getLogMsg(args...))
-	}
+	l.logger.Panic(getLogMsg(args...))
 }
 
 // Panicf logs to FATAL log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Panicf(format string, args ...any) {
-	if l.logger.Core().Enabled(zapcore.PanicLevel) {
-		l.logger.Panic(getLogMsgf(format, args...))
-	}
+	l.logger.Panic(getLogMsgf(format, args...))
 }
 
 // Panicln logs to INFO log. Arguments are handled in the manner of fmt.Println.
 func (l *zapLog) Panicln(args ...any) {
-	if l.logger.Core().Enabled(zapcore.PanicLevel) {
-		l.logger.Panic(fmt.Sprintf("%s\n", getLogMsg(args...)))
+	l.logger.Panic(fmt.Sprintf("%s\n", getLogMsg(args...)))
+}
+
+// Log logs msg with fields at level.
+func (l *zapLog) Log(level xlog.Level, msg string, fields ...xlog.Field) {
+	lvl, ok := levelToZapLevel[level]
+	if !ok || level == xlog.LevelOff || (level == xlog.LevelTrace && !xlog.IsTraceEnabled(l.name)) {
+		return
+	}
+	if ce := l.logger.Check(lvl, msg); ce != nil {
+		zapFields := toZapFields(fields)
+		if lvl == zapcore.FatalLevel {
+			zapFields = append(zapFields, fatalFields()...)
+		}
+		ce.Write(zapFields...)
+	}
+}
+
+// LogRecord logs msg with fields at level, with the time and caller given, see
+// xlog.RecordLogger.
+func (l *zapLog) LogRecord(t time.Time, pc uintptr, level xlog.Level, msg string, fields ...xlog.Field) {
+	lvl, ok := levelToZapLevel[level]
+	if !ok || level == xlog.LevelOff || (level == xlog.LevelTrace && !xlog.IsTraceEnabled(l.name)) {
+		return
+	}
+	ce := l.logger.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+	if !t.IsZero() {
+		ce.Time = t
+	}
+	ce.Caller = entryCaller(pc)
+	zapFields := toZapFields(fields)
+	if lvl == zapcore.FatalLevel {
+		zapFields = append(zapFields, fatalFields()...)
+	}
+	ce.Write(zapFields...)
+}
+
+// entryCaller returns the caller at pc, which is undefined if pc is zero.
+func entryCaller(pc uintptr) zapcore.EntryCaller {
+	if pc == 0 {
+		return zapcore.EntryCaller{}
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return zapcore.EntryCaller{
+		Defined:  frame.PC != 0,
+		PC:       frame.PC,
+		File:     frame.File,
+		Line:     frame.Line,
+		Function: frame.Function,
+	}
+}
+
+// TraceFields logs msg with fields to TRACE log.
+func (l *zapLog) TraceFields(msg string, fields ...xlog.Field) {
+	if !xlog.IsTraceEnabled(l.name) {
+		return
+	}
+	if ce := l.logger.Check(TraceLevel, msg); ce != nil {
+		ce.Write(toZapFields(fields)...)
+	}
+}
+
+// DebugFields logs msg with fields to DEBUG log.
+func (l *zapLog) DebugFields(msg string, fields ...xlog.Field) {
+	if ce := l.logger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(toZapFields(fields)...)
+	}
+}
+
+// InfoFields logs msg with fields to INFO log.
+func (l *zapLog) InfoFields(msg string, fields ...xlog.Field) {
+	if ce := l.logger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(toZapFields(fields)...)
+	}
+}
+
+// WarnFields logs msg with fields to WARNING log.
+func (l *zapLog) WarnFields(msg string, fields ...xlog.Field) {
+	if ce := l.logger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(toZapFields(fields)...)
+	}
+}
+
+// ErrorFields logs msg with fields to ERROR log.
+func (l *zapLog) ErrorFields(msg string, fields ...xlog.Field) {
+	if ce := l.logger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(toZapFields(fields)...)
+	}
+}
+
+// FatalFields logs msg with fields to FATAL log.
+func (l *zapLog) FatalFields(msg string, fields ...xlog.Field) {
+	if ce := l.logger.Check(zapcore.FatalLevel, msg); ce != nil {
+		ce.Write(append(toZapFields(fields), fatalFields()...)...)
+	}
+}
+
+// PanicFields logs msg with fields to PANIC log.
+func (l *zapLog) PanicFields(msg string, fields ...xlog.Field) {
+	if ce := l.logger.Check(zapcore.PanicLevel, msg); ce != nil {
+		ce.Write(toZapFields(fields)...)
 	}
 }
 
@@ -425,28 +708,128 @@ func (l *zapLog) Sync() error {
 	return l.logger.Sync()
 }
 
-// SetLevel sets output log level.
-func (l *zapLog) SetLevel(output string, level xlog.Level) {
-	i, e := strconv.Atoi(output)
-	if e != nil {
-		return
+// Close flushes the logger and closes its writers, which are shared by the
+// loggers derived from it by With, WithFields or Named. Logs written after
+// closing are dropped, and the write errors are reported by zap. Closing a
+// closed logger does nothing.
+func (l *zapLog) Close() error {
+	return l.CloseContext(context.Background())
+}
+
+// CloseContext is Close which returns when ctx is done, see xlog.ContextCloser.
+func (l *zapLog) CloseContext(ctx context.Context) error {
+	if l.state.current.Load().closed.Load() {
+		return nil
+	}
+	var errs error
+	synced := make(chan error, 1)
+	go func() {
+		synced <- l.logger.Sync()
+	}()
+	select {
+	case err := <-synced:
+		if err != nil && !isUnsyncable(err) && !errors.Is(err, ec.ErrWriterClosed) {
+			errs = multierror.Append(errs, err)
+		}
+	case <-ctx.Done():
+		errs = multierror.Append(errs, fmt.Errorf("sync not finished: %w", ctx.Err()))
 	}
-	if i < 0 || i >= len(l.levels) {
-		return
+	if err := l.state.current.Load().close(ctx); err != nil {
+		errs = multierror.Append(errs, err)
 	}
-	l.levels[i].SetLevel(levelToZapLevel[level])
+	return errs
 }
 
-// GetLevel gets output log level.
-func (l *zapLog) GetLevel(output string) xlog.Level {
-	i, e := strconv.Atoi(output)
-	if e != nil {
-		return xlog.LevelDebug
+var _ xlog.ContextCloser = (*zapLog)(nil)
+
+// SetLevel sets the log level of outputs, see resolveOutputs for how `output`
+// is matched.
+func (l *zapLog) SetLevel(output string, level xlog.Level) error {
+	lvl, ok := levelToZapLevel[level]
+	if !ok {
+		return fmt.Errorf("log: set level of output %q: invalid level %d", output, level)
 	}
-	if i < 0 || i >= len(l.levels) {
-		return xlog.LevelDebug
+	outputs := l.state.current.Load().outputs
+	indexes, err := resolveOutputs(outputs, output)
+	if err != nil {
+		return err
+	}
+	for _, i := range indexes {
+		outputs[i].level.SetLevel(lvl)
 	}
-	return zapLevelToLevel[l.levels[i].Level()]
+	return nil
+}
+
+// GetLevel gets the log level of outputs, see resolveOutputs for how `output`
+// is matched. If more than one output is matched, the lowest level is returned.
+func (l *zapLog) GetLevel(output string) (xlog.Level, error) {
+	outputs := l.state.current.Load().outputs
+	indexes, err := resolveOutputs(outputs, output)
+	if err != nil {
+		return xlog.LevelOff, err
+	}
+	lvl := outputs[indexes[0]].level.Level()
+	for _, i := range indexes[1:] {
+		if v := outputs[i].level.Level(); v < lvl {
+			lvl = v
+		}
+	}
+	return zapLevelToLevel[lvl], nil
+}
+
+// OutputLevels lists every output with its current level.
+func (l *zapLog) OutputLevels() []xlog.OutputLevel {
+	outputs := l.state.current.Load().outputs
+	levels := make([]xlog.OutputLevel, len(outputs))
+	for i, o := range outputs {
+		levels[i] = xlog.OutputLevel{
+			Index:  i,
+			Name:   o.name,
+			Writer: o.writer,
+			Level:  zapLevelToLevel[o.level.Level()],
+		}
+	}
+	return levels
+}
+
+// resolveOutputs returns the indexes of outputs matched by `name`, which is
+// one of:
+//   - "*", which matches all outputs.
+//   - the name of an output.
+//   - the writer of outputs, like "console" or "file".
+//   - the index of an output in the config, like "0".
+//
+// An error is returned if nothing is matched.
+func resolveOutputs(outputs []output, name string) ([]int, error) {
+	var indexes []int
+	if name == xlog.AllOutputs {
+		for i := range outputs {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		for i, o := range outputs {
+			if o.name != "" && o.name == name {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		for i, o := range outputs {
+			if o.writer == name {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(outputs) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("%w: %q", ec.ErrUnknownOutput, name)
+	}
+	return indexes, nil
 }
 
 // CustomTimeFormat customize time format.
@@ -461,6 +844,24 @@ func DefaultTimeFormat(t time.Time) []byte {
 	return defaultTimeFormat(t)
 }
 
+// RedirectSlog redirects the default slog logger to logger, so that packages
+// using log/slog write to the outputs of logger. Since slog.SetDefault also
+// redirects std log, the returned function recovers the previous slog logger,
+// std log flags, and redirects std log output to os.Stderr.
+func RedirectSlog(logger xlog.Logger) (func(), error) {
+	if logger == nil {
+		return nil, fmt.Errorf("log: redirect slog to nil logger")
+	}
+	prev := slog.Default()
+	flags := log.Flags()
+	slog.SetDefault(slog.New(xlog.NewSlogHandler(logger)))
+	return func() {
+		slog.SetDefault(prev)
+		log.SetFlags(flags)
+		log.SetOutput(os.Stderr)
+	}, nil
+}
+
 // RedirectStdLog redirects std log to trpc logger as log level INFO.
 // After redirection, log flag is zero, the prefix is empty.
 // The returned function may be used to recover log flag and prefix, and redirect output to
@@ -1,38 +1,93 @@
 package zap
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"github.com/hashicorp/go-multierror"
 	xlog "github.com/oyogames2023/zeus-log"
 	"github.com/oyogames2023/zeus-log/plugin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"io"
+	"sync"
+	"syscall"
 )
 
 const (
-	pluginType = "log"
+	pluginType        = "log"
+	defaultLoggerName = "default"
 )
 
+func init() {
+	xlog.DefaultConsoleWriterFactory = &ConsoleWriterFactory{}
+	xlog.DefaultFileWriterFactory = &FileWriterFactory{}
+	// Registering twice panics, which happens if another log backend
+	// registers the same names.
+	xlog.RegisterWriter(xlog.OutputConsole, xlog.DefaultConsoleWriterFactory)
+	xlog.RegisterWriter(xlog.OutputFile, xlog.DefaultFileWriterFactory)
+	xlog.RegisterFormatter(xlog.FormatterConsole, &ConsoleFormatterFactory{})
+	xlog.RegisterFormatter(xlog.FormatterJSON, &JSONFormatterFactory{})
+
+	xlog.DefaultFactory = DefaultFactory
+	plugin.Register(defaultLoggerName, DefaultFactory)
+
+	// Invalid overrides are not applied, and the default config is used if the
+	// overridden one fails to build.
+	cfg, overrides, _ := xlog.ApplyEnvOverrides(defaultLoggerName, defaultConfig)
+	logger, err := NewZapLogE(cfg)
+	if err != nil {
+		logger = NewZapLog(defaultConfig)
+	} else {
+		xlog.RecordEnvOverrides(defaultLoggerName, overrides)
+	}
+	xlog.Register(defaultLoggerName, logger)
+}
+
+// DefaultFactory is the zap log plugin factory, registered as the default log
+// factory of zeus_log.
+var DefaultFactory = &Factory{}
+
 // Decoder decodes the log.
 type Decoder struct {
 	OutputConfig *xlog.OutputConfig
 	Core         zapcore.Core
 	ZapLevel     zap.AtomicLevel
+	// Closer is optionally set by writers which hold resources, like files.
+	// It's closed when the output is removed or the logger is closed.
+	Closer io.Closer
 }
 
-// Decode decodes writer configuration, copy one.
+// Decode decodes writer configuration. A **OutputConfig gets a copy of the
+// output config, any other cfg is decoded from the remote_config block of the
+// output strictly, see plugin.YAMLNodeDecoder, so that third-party writers can
+// define their own config.
 func (d *Decoder) Decode(cfg interface{}) error {
-	output, ok := cfg.(**xlog.OutputConfig)
-	if !ok {
-		return fmt.Errorf("decoder config type:%T invalid, not **OutputConfig", cfg)
+	if output, ok := cfg.(**xlog.OutputConfig); ok {
+		*output = d.OutputConfig
+		return nil
+	}
+	if d.OutputConfig == nil {
+		return fmt.Errorf("decoder config type:%T invalid, no output config", cfg)
+	}
+	if err := plugin.NewYAMLNodeDecoder(&d.OutputConfig.RemoteConfig).Decode(cfg); err != nil {
+		return fmt.Errorf("decode remote_config: %w", err)
 	}
-	*output = d.OutputConfig
 	return nil
 }
 
 // Factory is the log plugin factory.
 // When server start, the configuration is feed to Factory to generate a log instance.
-type Factory struct{}
+// It implements plugin.Flusher and plugin.Closer for the loggers it sets up.
+type Factory struct {
+	mu      sync.Mutex
+	loggers []*zapLog
+}
+
+var (
+	_ plugin.Flusher = (*Factory)(nil)
+	_ plugin.Closer  = (*Factory)(nil)
+)
 
 // Type returns the log plugin type.
 func (f *Factory) Type() string {
@@ -48,14 +103,63 @@ func (f *Factory) Setup(name string, dec plugin.Decoder) error {
 	if err != nil {
 		return err
 	}
-	logger := NewZapLogWithCallerSkip(cfg, callerSkip)
-	if logger == nil {
-		return errors.New("new zap logger fail")
+	logger, err := newZapLog(cfg, callerSkip)
+	if err != nil {
+		return err
+	}
+	if err := xlog.RegisterE(name, logger); err != nil {
+		_ = logger.Close()
+		return err
 	}
-	xlog.Register(name, logger)
+	f.mu.Lock()
+	f.loggers = append(f.loggers, logger)
+	f.mu.Unlock()
 	return nil
 }
 
+// Flush syncs the loggers set up by the factory.
+func (f *Factory) Flush() error {
+	var errs error
+	for _, l := range f.setupLoggers() {
+		if err := l.Sync(); err != nil && !isUnsyncable(err) {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Close closes the loggers set up by the factory, and forgets them. Loggers
+// not reached when ctx is done are left open.
+func (f *Factory) Close(ctx context.Context) error {
+	f.mu.Lock()
+	loggers := f.loggers
+	f.loggers = nil
+	f.mu.Unlock()
+
+	var errs error
+	for i, l := range loggers {
+		if err := ctx.Err(); err != nil {
+			return multierror.Append(errs, fmt.Errorf("%d loggers left open: %w", len(loggers)-i, err))
+		}
+		if err := l.CloseContext(ctx); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (f *Factory) setupLoggers() []*zapLog {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*zapLog(nil), f.loggers...)
+}
+
+// isUnsyncable reports whether err is returned by syncing a file which does
+// not support it, like stdout of a terminal.
+func isUnsyncable(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)
+}
+
 func (f *Factory) setupConfig(configDec plugin.Decoder) (xlog.Config, int, error) {
 	cfg := xlog.Config{}
 	if err := configDec.Decode(&cfg); err != nil {
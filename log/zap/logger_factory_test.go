@@ -0,0 +1,70 @@
+package zap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+)
+
+func TestFactoryCloseThenLoggerCloseClosesOnce(t *testing.T) {
+	f := &Factory{}
+	cfg := xlog.Config{{Name: "factory-close-out", Writer: testWriter}}
+	if err := f.Setup("factory-close", &configDecoder{cfg}); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	l := xlog.Get("factory-close")
+	l.Info("before")
+
+	// Like Shutdown, which closes the factories and then the registered loggers.
+	if err := f.Close(context.Background()); err != nil {
+		t.Fatalf("factory Close: %v", err)
+	}
+	if err := l.(xlog.ContextCloser).CloseContext(context.Background()); err != nil {
+		t.Errorf("CloseContext of a closed logger err = %v, want nil", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close of a closed logger err = %v, want nil", err)
+	}
+	buf := testOutput("factory-close-out")[0]
+	if n := buf.closeCount(); n != 1 {
+		t.Errorf("writer closed %d times, want once", n)
+	}
+	if lines := buf.lines(); len(lines) != 1 {
+		t.Errorf("lines = %q, want the log written before closing", lines)
+	}
+	if err := f.Close(context.Background()); err != nil {
+		t.Errorf("second factory Close err = %v, want nil", err)
+	}
+}
+
+// blockingCloser is a writer closer which blocks until released.
+type blockingCloser struct {
+	release chan struct{}
+}
+
+func (c blockingCloser) Close() error {
+	<-c.release
+	return nil
+}
+
+func TestCloseContextDeadline(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{Writer: testWriter}})
+	release := make(chan struct{})
+	defer close(release)
+	st := l.state.current.Load()
+	st.closers = append(st.closers, blockingCloser{release})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := l.CloseContext(ctx)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("CloseContext took %v, want it bounded by ctx", d)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CloseContext err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
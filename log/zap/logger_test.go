@@ -0,0 +1,534 @@
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	xlog "github.com/oyogames2023/zeus-log"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+	"github.com/oyogames2023/zeus-log/plugin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// testWriter is the writer of outputs in tests. Each time an output is set up,
+// it writes to a new testBuffer kept by the name of the output.
+const testWriter = "test"
+
+func init() {
+	xlog.RegisterWriter(testWriter, &testWriterFactory{})
+}
+
+var (
+	testBuffersMu sync.Mutex
+	testBuffers   = make(map[string][]*testBuffer)
+)
+
+// testBuffer is a zapcore.WriteSyncer which keeps the written logs in memory.
+type testBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+	// closes counts the calls of Close.
+	closes int
+}
+
+func (b *testBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, ec.ErrWriterClosed
+	}
+	return b.buf.Write(p)
+}
+
+func (b *testBuffer) Sync() error {
+	return nil
+}
+
+func (b *testBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.closes++
+	return nil
+}
+
+func (b *testBuffer) closeCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closes
+}
+
+func (b *testBuffer) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// lines returns the written lines.
+func (b *testBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := strings.TrimSuffix(b.buf.String(), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+type testWriterFactory struct{}
+
+func (f *testWriterFactory) Type() string {
+	return pluginType
+}
+
+func (f *testWriterFactory) Setup(name string, dec plugin.Decoder) error {
+	decoder := dec.(*Decoder)
+	buf := &testBuffer{}
+	core, level, err := NewCore(decoder.OutputConfig, buf)
+	if err != nil {
+		return err
+	}
+	decoder.Core, decoder.ZapLevel, decoder.Closer = core, level, buf
+	testBuffersMu.Lock()
+	testBuffers[decoder.OutputConfig.Name] = append(testBuffers[decoder.OutputConfig.Name], buf)
+	testBuffersMu.Unlock()
+	return nil
+}
+
+// testOutput returns the buffers of the test output by name, in the order they
+// are set up.
+func testOutput(name string) []*testBuffer {
+	testBuffersMu.Lock()
+	defer testBuffersMu.Unlock()
+	return append([]*testBuffer(nil), testBuffers[name]...)
+}
+
+// testLines returns the lines written by the latest setup of the test output.
+func testLines(t *testing.T, name string) []string {
+	t.Helper()
+	bufs := testOutput(name)
+	if len(bufs) == 0 {
+		t.Fatalf("test output %s not set up", name)
+	}
+	return bufs[len(bufs)-1].lines()
+}
+
+// testEntries returns the lines written by the latest setup of the test output
+// decoded as JSON.
+func testEntries(t *testing.T, name string) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range testLines(t, name) {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// newTestLog creates a logger of cfg, whose outputs of the test writer are
+// closed when the test ends.
+func newTestLog(t *testing.T, cfg xlog.Config) *zapLog {
+	t.Helper()
+	l, err := newZapLog(cfg, 1)
+	if err != nil {
+		t.Fatalf("newZapLog: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func newObservedLog(skip int) (*zapLog, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &zapLog{
+		logger: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(skip)),
+	}, logs
+}
+
+func TestZapLogWith(t *testing.T) {
+	type stringer struct{ v int }
+	tests := []struct {
+		name string
+		args []any
+		want map[string]any
+	}{
+		{
+			name: "no args",
+			args: nil,
+			want: map[string]any{},
+		},
+		{
+			name: "key value pairs",
+			args: []any{"uid", 42, "room", "lobby"},
+			want: map[string]any{"uid": int64(42), "room": "lobby"},
+		},
+		{
+			name: "odd argument count",
+			args: []any{"uid", 42, "room"},
+			want: map[string]any{"uid": int64(42), badKey: "room"},
+		},
+		{
+			name: "single dangling key",
+			args: []any{"uid"},
+			want: map[string]any{badKey: "uid"},
+		},
+		{
+			name: "non string key",
+			args: []any{7, "uid", 42},
+			want: map[string]any{badKey: int64(7), "uid": int64(42)},
+		},
+		{
+			name: "non string key at the end",
+			args: []any{"uid", 42, true},
+			want: map[string]any{"uid": int64(42), badKey: true},
+		},
+		{
+			name: "nil key",
+			args: []any{nil, "uid", 42},
+			want: map[string]any{badKey: nil, "uid": int64(42)},
+		},
+		{
+			name: "struct value",
+			args: []any{"obj", stringer{v: 1}},
+			want: map[string]any{"obj": stringer{v: 1}},
+		},
+		{
+			name: "xlog field",
+			args: []any{xlog.Field{Key: "uid", Value: 42}},
+			want: map[string]any{"uid": int64(42)},
+		},
+		{
+			name: "xlog field between pairs",
+			args: []any{"a", 1, xlog.Field{Key: "b", Value: "2"}, "c", 3},
+			want: map[string]any{"a": int64(1), "b": "2", "c": int64(3)},
+		},
+		{
+			name: "xlog field after a key is used as its value",
+			args: []any{"a", xlog.Field{Key: "b", Value: 2}},
+			want: map[string]any{"a": xlog.Field{Key: "b", Value: 2}},
+		},
+		{
+			name: "zap field",
+			args: []any{zap.String("a", "1"), "b", 2},
+			want: map[string]any{"a": "1", "b": int64(2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := newObservedLog(1)
+			child := l.With(tt.args...)
+			if child == nil {
+				t.Fatal("With returned nil logger")
+			}
+			child.Info("msg")
+			entries := logs.TakeAll()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			if got := entries[0].ContextMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("context = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZapLogWithCallerSkip(t *testing.T) {
+	l, logs := newObservedLog(1)
+	l.With("a", 1).Info("with")
+	l.WithFields(xlog.Field{Key: "a", Value: 1}).Info("with fields")
+	entries := logs.TakeAll()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	want := "github.com/oyogames2023/zeus-log/log/zap.TestZapLogWithCallerSkip"
+	for _, e := range entries {
+		if e.Caller.Function != want {
+			t.Errorf("%s: caller = %s, want %s", e.Message, e.Caller.Function, want)
+		}
+	}
+}
+
+func TestSetLevelResolvesOutputs(t *testing.T) {
+	cfg := xlog.Config{
+		{Name: "levels-a", Writer: testWriter, Level: "debug"},
+		{Name: "levels-b", Writer: testWriter, Level: "info"},
+		{Writer: xlog.OutputConsole, Level: "warn"},
+	}
+	tests := []struct {
+		name   string
+		output string
+		want   []xlog.Level
+	}{
+		{name: "by name", output: "levels-b", want: []xlog.Level{xlog.LevelDebug, xlog.LevelError, xlog.LevelWarn}},
+		{name: "by writer", output: testWriter, want: []xlog.Level{xlog.LevelError, xlog.LevelError, xlog.LevelWarn}},
+		{name: "by index", output: "2", want: []xlog.Level{xlog.LevelDebug, xlog.LevelInfo, xlog.LevelError}},
+		{name: "all", output: xlog.AllOutputs, want: []xlog.Level{xlog.LevelError, xlog.LevelError, xlog.LevelError}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLog(t, cfg)
+			if err := l.SetLevel(tt.output, xlog.LevelError); err != nil {
+				t.Fatalf("SetLevel: %v", err)
+			}
+			levels := l.OutputLevels()
+			for i, want := range tt.want {
+				if levels[i].Level != want {
+					t.Errorf("output %d level = %v, want %v", i, levels[i].Level, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetLevelUnknownOutput(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{Name: "levels-unknown", Writer: testWriter}})
+	for _, output := range []string{"missing", "1", "-1", ""} {
+		if err := l.SetLevel(output, xlog.LevelError); !errors.Is(err, ec.ErrUnknownOutput) {
+			t.Errorf("SetLevel(%q) err = %v, want %v", output, err, ec.ErrUnknownOutput)
+		}
+		if _, err := l.GetLevel(output); !errors.Is(err, ec.ErrUnknownOutput) {
+			t.Errorf("GetLevel(%q) err = %v, want %v", output, err, ec.ErrUnknownOutput)
+		}
+	}
+}
+
+func TestGetLevelReturnsLowest(t *testing.T) {
+	l := newTestLog(t, xlog.Config{
+		{Name: "lowest-a", Writer: testWriter, Level: "warn"},
+		{Name: "lowest-b", Writer: testWriter, Level: "trace"},
+	})
+	got, err := l.GetLevel(xlog.AllOutputs)
+	if err != nil {
+		t.Fatalf("GetLevel: %v", err)
+	}
+	if got != xlog.LevelTrace {
+		t.Errorf("level = %v, want %v", got, xlog.LevelTrace)
+	}
+}
+
+func TestOutputLevels(t *testing.T) {
+	l := newTestLog(t, xlog.Config{
+		{Name: "list-a", Writer: testWriter, Level: "info"},
+		{Writer: testWriter, Level: "error"},
+	})
+	want := []xlog.OutputLevel{
+		{Index: 0, Name: "list-a", Writer: testWriter, Level: xlog.LevelInfo},
+		{Index: 1, Writer: testWriter, Level: xlog.LevelError},
+	}
+	if got := l.OutputLevels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("OutputLevels = %+v, want %+v", got, want)
+	}
+}
+
+func TestLevelOffDisablesOutput(t *testing.T) {
+	l := newTestLog(t, xlog.Config{
+		{Name: "off-a", Writer: testWriter, Formatter: xlog.FormatterJSON, Level: "off"},
+		{Name: "off-b", Writer: testWriter, Formatter: xlog.FormatterJSON, Level: "debug"},
+	})
+	l.Error("first")
+	if err := l.SetLevel("off-b", xlog.LevelOff); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	if l.Enabled(xlog.LevelPanic) {
+		t.Error("Enabled(panic) = true with all outputs off")
+	}
+	l.Error("second")
+	if lines := testLines(t, "off-a"); len(lines) != 0 {
+		t.Errorf("off-a lines = %q, want none", lines)
+	}
+	if lines := testLines(t, "off-b"); len(lines) != 1 {
+		t.Errorf("off-b lines = %q, want only the first", lines)
+	}
+	if got, _ := l.GetLevel("off-b"); got != xlog.LevelOff {
+		t.Errorf("GetLevel = %v, want %v", got, xlog.LevelOff)
+	}
+}
+
+// failingWriter is a writer whose setup always fails.
+const failingWriter = "test-failing"
+
+func init() {
+	xlog.RegisterWriter(failingWriter, &failingWriterFactory{})
+}
+
+type failingWriterFactory struct{}
+
+func (f *failingWriterFactory) Type() string {
+	return pluginType
+}
+
+func (f *failingWriterFactory) Setup(string, plugin.Decoder) error {
+	return errors.New("no connection")
+}
+
+func TestNewZapLogEInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  xlog.Config
+		want []xlog.ConfigError
+	}{
+		{
+			name: "empty",
+			cfg:  xlog.Config{},
+			want: []xlog.ConfigError{{Index: -1}},
+		},
+		{
+			name: "unregistered writer",
+			cfg:  xlog.Config{{Writer: "kafka"}},
+			want: []xlog.ConfigError{{Index: 0, Field: "writer"}},
+		},
+		{
+			name: "every problem of every output",
+			cfg: xlog.Config{
+				{Writer: testWriter, Level: "verbose", Formatter: "xml"},
+				{Writer: xlog.OutputFile, WriterConfig: xlog.WriterConfig{MaxSize: -1, RollType: "weekly"}},
+			},
+			want: []xlog.ConfigError{
+				{Index: 0, Field: "formatter"},
+				{Index: 0, Field: "level"},
+				{Index: 1, Field: "writer_config.file_name"},
+				{Index: 1, Field: "writer_config.roll_type"},
+				{Index: 1, Field: "writer_config.max_size"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewZapLogE(tt.cfg)
+			if l != nil {
+				t.Errorf("logger = %v, want nil", l)
+			}
+			merr, ok := err.(*multierror.Error)
+			if !ok {
+				merr = &multierror.Error{Errors: []error{err}}
+			}
+			if len(merr.Errors) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(merr.Errors), len(tt.want), err)
+			}
+			for i, e := range merr.Errors {
+				var ce *xlog.ConfigError
+				if !errors.As(e, &ce) {
+					t.Fatalf("error %d = %v, want *ConfigError", i, e)
+				}
+				if ce.Index != tt.want[i].Index || ce.Field != tt.want[i].Field {
+					t.Errorf("error %d = %v, want output[%d].%s", i, e, tt.want[i].Index, tt.want[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestNewZapLogEWriterSetupFail(t *testing.T) {
+	l, err := NewZapLogE(xlog.Config{
+		{Name: "setup-fail-out", Writer: testWriter},
+		{Writer: failingWriter},
+	})
+	if l != nil || err == nil {
+		t.Fatalf("NewZapLogE = %v, %v, want error", l, err)
+	}
+	if want := "output[1]: writer test-failing setup fail: no connection"; !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	if bufs := testOutput("setup-fail-out"); len(bufs) != 1 || !bufs[0].isClosed() {
+		t.Error("writer of the output set up before the failing one is not closed")
+	}
+}
+
+func TestNewZapLogPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewZapLog with invalid config did not panic")
+		}
+	}()
+	NewZapLog(xlog.Config{{Writer: "kafka"}})
+}
+
+func TestCloseDropsLaterLogs(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{Name: "close-out", Writer: testWriter}})
+	child := l.With("k", 1)
+	l.Info("before")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	child.Info("after")
+	bufs := testOutput("close-out")
+	if !bufs[0].isClosed() {
+		t.Error("writer not closed")
+	}
+	if lines := bufs[0].lines(); len(lines) != 1 {
+		t.Errorf("lines = %q, want only the one before closing", lines)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close err = %v, want nil", err)
+	}
+}
+
+func TestFatalWithReplacedExitFunc(t *testing.T) {
+	var codes []int
+	defer xlog.SetExitFunc(func(code int) { codes = append(codes, code) })()
+	l := newTestLog(t, xlog.Config{{Name: "fatal-out", Writer: testWriter, Formatter: xlog.FormatterJSON}})
+	l.Fatal("first")
+	l.FatalFields("second")
+	if !reflect.DeepEqual(codes, []int{1, 1}) {
+		t.Errorf("exit codes = %v, want [1 1]", codes)
+	}
+	entries := testEntries(t, "fatal-out")
+	if len(entries) != 2 || entries[0]["M"] != "first" || entries[1]["M"] != "second" {
+		t.Errorf("entries = %v, want both fatal logs", entries)
+	}
+	if testOutput("fatal-out")[0].isClosed() {
+		t.Error("writer closed by a fatal log with a replaced exit function")
+	}
+}
+
+func TestFatalAndPanicWithEveryOutputOff(t *testing.T) {
+	var codes []int
+	defer xlog.SetExitFunc(func(code int) { codes = append(codes, code) })()
+	l := newTestLog(t, xlog.Config{{Name: "off-out", Writer: testWriter, Level: "off"}})
+
+	fatals := []func(){
+		func() { l.Fatal("fatal") },
+		func() { l.Fatalf("%s", "fatalf") },
+		func() { l.Fatalln("fatalln") },
+		func() { l.FatalFields("fatal fields") },
+		func() { l.Log(xlog.LevelFatal, "log") },
+	}
+	for _, fatal := range fatals {
+		fatal()
+	}
+	if len(codes) != len(fatals) {
+		t.Errorf("exit codes = %v, want one per fatal log", codes)
+	}
+
+	panics := map[string]func(){
+		"Panic":       func() { l.Panic("panic") },
+		"Panicf":      func() { l.Panicf("%s", "panicf") },
+		"Panicln":     func() { l.Panicln("panicln") },
+		"PanicFields": func() { l.PanicFields("panic fields") },
+		"Log":         func() { l.Log(xlog.LevelPanic, "log") },
+	}
+	for name, p := range panics {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			p()
+		}()
+	}
+	if lines := testLines(t, "off-out"); len(lines) != 0 {
+		t.Errorf("lines = %q, want none", lines)
+	}
+}
@@ -0,0 +1,336 @@
+package zap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// output is an output of zapLog.
+type output struct {
+	name   string
+	writer string
+	level  zap.AtomicLevel
+	// sampling is nil if sampling is not enabled.
+	sampling *samplingCounter
+}
+
+// loggerState is everything a zapLog builds from its config. It's replaced as
+// a whole on reload.
+type loggerState struct {
+	cfg     xlog.Config
+	core    zapcore.Core
+	outputs []output
+	closers []io.Closer
+	// stackLevel is the level from which stack traces are captured for any
+	// output, and stackDepth the depth of the deepest ones, see stackCore.
+	stackLevel zapcore.Level
+	stackDepth int
+	// stoppers write logs to the outputs in the background, like summaries
+	// of shed logs. They are stopped before the writers are closed.
+	stoppers []stopper
+
+	// writes counts the logs being written to the state, from the check of an
+	// entry to the end of its write.
+	writes atomic.Int64
+	// retired is set once the state is replaced, then drained is closed when
+	// no log is being written to it.
+	retired   atomic.Bool
+	drained   chan struct{}
+	drainOnce sync.Once
+	// closed is set by the first close, later ones do nothing.
+	closed atomic.Bool
+}
+
+// newLoggerState validates cfg and sets up the writers of all outputs.
+func newLoggerState(cfg xlog.Config) (*loggerState, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	st := &loggerState{cfg: cfg, stackLevel: OffLevel, drained: make(chan struct{})}
+	cores := make([]zapcore.Core, 0, len(cfg))
+	stackCores := make([]*stackCore, 0, len(cfg))
+	for i := range cfg {
+		c := cfg[i]
+		writer := xlog.GetWriter(c.Writer)
+		decoder := &Decoder{OutputConfig: &c}
+		if err := writer.Setup(c.Writer, decoder); err != nil {
+			_ = st.close(context.Background())
+			return nil, fmt.Errorf("log: output[%d]: writer %s setup fail: %w", i, c.Writer, err)
+		}
+		if decoder.Closer != nil {
+			st.closers = append(st.closers, decoder.Closer)
+		}
+		o := output{
+			name:   c.Name,
+			writer: c.Writer,
+			level:  decoder.ZapLevel,
+		}
+		// Samplers drop logs before they are checked for duplicates, and
+		// duplicates are suppressed before they are counted in the throttle
+		// budget. Stack traces are trimmed last, for the logs written.
+		sc := newStackCore(decoder.Core, &c)
+		stackCores = append(stackCores, sc)
+		st.stackLevel = min(st.stackLevel, sc.level)
+		st.stackDepth = max(st.stackDepth, sc.depth)
+		var core zapcore.Core = sc
+		if c.Throttle.Enabled() {
+			var t *throttle
+			core, t = newThrottledCore(core, c.Throttle)
+			st.stoppers = append(st.stoppers, t)
+		}
+		if c.Dedup.Enabled() {
+			var d *deduper
+			core, d = newDedupCore(core, c.Dedup)
+			st.stoppers = append(st.stoppers, d)
+		}
+		if c.Sampling.Enabled() {
+			o.sampling = &samplingCounter{}
+			core = newSampledCore(core, c.Sampling, o.sampling)
+		}
+		cores = append(cores, core)
+		st.outputs = append(st.outputs, o)
+	}
+	for _, sc := range stackCores {
+		sc.captureLevel = st.stackLevel
+	}
+	st.core = zapcore.NewTee(cores...)
+	return st, nil
+}
+
+// release ends a write counted by stateHolder.acquire.
+func (st *loggerState) release() {
+	if st.writes.Add(-1) == 0 && st.retired.Load() {
+		st.drainOnce.Do(func() { close(st.drained) })
+	}
+}
+
+// retire marks the replaced state, and returns a channel closed when no log is
+// being written to it. New writes never start on a retired state.
+func (st *loggerState) retire() <-chan struct{} {
+	st.retired.Store(true)
+	if st.writes.Load() == 0 {
+		st.drainOnce.Do(func() { close(st.drained) })
+	}
+	return st.drained
+}
+
+// stopper is a background writer of an output, see loggerState.stoppers.
+type stopper interface {
+	// stop stops writing in the background and flushes what is pending.
+	stop() error
+}
+
+// close stops the background writers and closes the writers of the state,
+// and returns when ctx is done. Only the first call closes them.
+func (st *loggerState) close(ctx context.Context) error {
+	if !st.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	var errs error
+	for _, s := range st.stoppers {
+		if err := s.stop(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	for _, c := range st.closers {
+		if err := closeContext(ctx, c); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// contextCloser is implemented by writers which close within a context, like
+// rollwriter.AsyncRollWriter.
+type contextCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
+// closeContext closes c, and returns when ctx is done even if c is not closed.
+func closeContext(ctx context.Context, c io.Closer) error {
+	if cc, ok := c.(contextCloser); ok {
+		return cc.CloseContext(ctx)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("close %T not finished: %w", c, ctx.Err())
+	}
+}
+
+// stateHolder holds the current loggerState of a zapLog and its derived loggers.
+type stateHolder struct {
+	// mu serializes reloads.
+	mu      sync.Mutex
+	current atomic.Pointer[loggerState]
+}
+
+// acquire returns the current state, and counts a write to it until released.
+// The state is loaded again after counting, so that no write starts on a state
+// which is already replaced and waiting for writes to drain.
+func (h *stateHolder) acquire() *loggerState {
+	for {
+		st := h.current.Load()
+		st.writes.Add(1)
+		if h.current.Load() == st {
+			return st
+		}
+		st.release()
+	}
+}
+
+// reloadableCore is a zapcore.Core which delegates to the core of the current
+// loggerState, so loggers derived from a zapLog before a reload, like the ones
+// returned by With, write to the new outputs after it.
+type reloadableCore struct {
+	holder *stateHolder
+	// fields are the context fields added by With.
+	fields []zapcore.Field
+	// derived caches the current core with fields added.
+	derived atomic.Pointer[derivedCore]
+}
+
+// derivedCore is the core of a loggerState with context fields added.
+type derivedCore struct {
+	from *loggerState
+	core zapcore.Core
+}
+
+// coreOf returns the core of st to delegate to.
+func (c *reloadableCore) coreOf(st *loggerState) zapcore.Core {
+	if len(c.fields) == 0 {
+		return st.core
+	}
+	if d := c.derived.Load(); d != nil && d.from == st {
+		return d.core
+	}
+	d := &derivedCore{from: st, core: st.core.With(c.fields)}
+	c.derived.Store(d)
+	return d.core
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *reloadableCore) Enabled(lvl zapcore.Level) bool {
+	return c.holder.current.Load().core.Enabled(lvl)
+}
+
+// With implements zapcore.Core.
+func (c *reloadableCore) With(fields []zapcore.Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &reloadableCore{holder: c.holder, fields: merged}
+}
+
+// Check implements zapcore.Core. The write to the current state is counted
+// from here, and released by a releaseCore added after the cores of the state,
+// so that the state is not closed by Reload before the entry is written. The
+// stack trace of the entry is captured here once for all outputs, where the
+// caller of the log is still on the stack.
+func (c *reloadableCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	st := c.holder.acquire()
+	if ce = c.coreOf(st).Check(ent, ce); ce == nil {
+		st.release()
+		return nil
+	}
+	if ent.Level >= st.stackLevel || ent.Stack == stackRequested {
+		ce.Entry.Stack = captureStack(st.stackDepth)
+	}
+	return ce.AddCore(ent, releaseCore{st: st})
+}
+
+// Write implements zapcore.Core.
+func (c *reloadableCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	st := c.holder.acquire()
+	defer st.release()
+	return c.coreOf(st).Write(ent, fields)
+}
+
+// Sync implements zapcore.Core.
+func (c *reloadableCore) Sync() error {
+	st := c.holder.acquire()
+	defer st.release()
+	return c.coreOf(st).Sync()
+}
+
+// releaseCore releases the write counted by reloadableCore.Check when the entry
+// is written. It's only added to checked entries, so only Write is called.
+type releaseCore struct {
+	zapcore.LevelEnabler
+	st *loggerState
+}
+
+// With implements zapcore.Core.
+func (c releaseCore) With([]zapcore.Field) zapcore.Core {
+	return c
+}
+
+// Check implements zapcore.Core.
+func (c releaseCore) Check(_ zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c releaseCore) Write(zapcore.Entry, []zapcore.Field) error {
+	c.st.release()
+	return nil
+}
+
+// Sync implements zapcore.Core.
+func (c releaseCore) Sync() error {
+	return nil
+}
+
+// Reload replaces the outputs of the logger, and all loggers derived from it,
+// with the ones built from cfg. The swap is atomic: each log is written either
+// to the old outputs or to the new ones. The old writers are closed within ctx
+// once the logs being written to them are done, or in the background if that
+// does not happen before ctx is done. If cfg is invalid or any writer fails to
+// set up, the old config is kept and an error is returned.
+//
+// Levels changed by SetLevel are reset to the ones in cfg.
+func (l *zapLog) Reload(ctx context.Context, cfg xlog.Config) (xlog.ConfigDiff, error) {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	st, err := newLoggerState(cfg)
+	if err != nil {
+		return xlog.ConfigDiff{}, err
+	}
+	old := l.state.current.Swap(st)
+	diff := xlog.DiffConfig(old.cfg, cfg)
+
+	// The new config is in use, so only report close errors like writers do.
+	drained := old.retire()
+	select {
+	case <-drained:
+		if err := old.close(ctx); err != nil {
+			fmt.Printf("log: close replaced writers err: %+v\n", err)
+		}
+	case <-ctx.Done():
+		go func() {
+			<-drained
+			if err := old.close(context.Background()); err != nil {
+				fmt.Printf("log: close replaced writers err: %+v\n", err)
+			}
+		}()
+	}
+	return diff, nil
+}
+
+var _ xlog.Reloader = (*zapLog)(nil)
@@ -0,0 +1,155 @@
+package zap
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReloadConcurrentLogging(t *testing.T) {
+	cfg := xlog.Config{{Name: "reload-swap", Writer: testWriter, Level: "info"}}
+	l := newTestLog(t, cfg)
+	child := l.With("k", 1)
+
+	var (
+		wg      sync.WaitGroup
+		written atomic.Int64
+		stop    = make(chan struct{})
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 2000; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				child.Info("line")
+				written.Add(1)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := l.Reload(context.Background(), cfg); err != nil {
+			t.Fatalf("Reload: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	bufs := testOutput("reload-swap")
+	if len(bufs) != 21 {
+		t.Fatalf("output set up %d times, want 21", len(bufs))
+	}
+	var lines int64
+	for i, b := range bufs {
+		lines += int64(len(b.lines()))
+		if closed := b.isClosed(); closed != (i < len(bufs)-1) {
+			t.Errorf("buffer %d closed = %v", i, closed)
+		}
+	}
+	if lines != written.Load() {
+		t.Errorf("got %d lines, want %d", lines, written.Load())
+	}
+}
+
+func TestReloadWaitsForWrites(t *testing.T) {
+	cfg := xlog.Config{{Name: "reload-wait", Writer: testWriter, Formatter: xlog.FormatterJSON}}
+	l := newTestLog(t, cfg)
+	// A write checked before the reload and not written yet.
+	ce := l.logger.Check(zapcore.InfoLevel, "in flight")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := l.Reload(ctx, cfg); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Reload took %v, want it bounded by ctx", d)
+	}
+	old := testOutput("reload-wait")[0]
+	if old.isClosed() {
+		t.Fatal("old writer closed with a write in flight")
+	}
+	ce.Write()
+	if lines := old.lines(); len(lines) != 1 {
+		t.Errorf("old writer lines = %q, want the one in flight", lines)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !old.isClosed() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !old.isClosed() {
+		t.Error("old writer not closed after the write in flight is done")
+	}
+}
+
+func TestReloadDiff(t *testing.T) {
+	l := newTestLog(t, xlog.Config{
+		{Name: "diff-a", Writer: testWriter, Level: "info"},
+		{Writer: xlog.OutputConsole},
+	})
+	diff, err := l.Reload(context.Background(), xlog.Config{
+		{Name: "diff-a", Writer: testWriter, Level: "error"},
+		{Writer: testWriter},
+	})
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	want := xlog.ConfigDiff{
+		Added:   []string{"test#0"},
+		Removed: []string{"console#0"},
+		Changed: []string{`diff-a: level: "info" -> "error"`},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff = %#v, want %#v", diff, want)
+	}
+	if got, _ := l.GetLevel("diff-a"); got != xlog.LevelError {
+		t.Errorf("level after reload = %v, want %v", got, xlog.LevelError)
+	}
+}
+
+func TestReloadInvalidConfigKeepsOld(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{Name: "reload-invalid", Writer: testWriter}})
+	if _, err := l.Reload(context.Background(), xlog.Config{{Writer: "kafka"}}); err == nil {
+		t.Fatal("Reload with invalid config succeeded")
+	}
+	l.Info("kept")
+	if lines := testLines(t, "reload-invalid"); len(lines) != 1 {
+		t.Errorf("lines = %q, want one written to the old output", lines)
+	}
+}
+
+func TestReloadFromBytesNoChange(t *testing.T) {
+	doc := []byte(`
+log:
+  reload-same:
+    - name: reload-same-out
+      writer: test
+      level: info
+      sampling:
+        initial: 10
+      throttle:
+        entries_per_second: 100
+`)
+	if err := xlog.SetupFromBytes(doc); err != nil {
+		t.Fatalf("SetupFromBytes: %v", err)
+	}
+	t.Cleanup(func() { _ = xlog.Get("reload-same").(*zapLog).Close() })
+	diffs, err := xlog.ReloadFromBytes(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("ReloadFromBytes: %v", err)
+	}
+	if d := diffs["reload-same"]; !d.Empty() {
+		t.Errorf("diff = %v, want no change", d)
+	}
+}
@@ -0,0 +1,167 @@
+package zap
+
+import (
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSamplingTick is the sampling interval if it's not configured.
+const defaultSamplingTick = time.Second
+
+// SamplingCounter is the counter of an output with sampling enabled.
+type SamplingCounter struct {
+	// Index is the index of the output in the config.
+	Index int
+	// Name is the name of the output, which may be empty.
+	Name string
+	// Writer is the writer type of the output.
+	Writer string
+	// Dropped are the numbers of logs dropped by sampling, by level, since
+	// the output is set up.
+	Dropped map[xlog.Level]uint64
+}
+
+// SamplingCounters returns the counters of the outputs of logger with sampling
+// enabled. Loggers not created by this package have no counters.
+func SamplingCounters(logger xlog.Logger) []SamplingCounter {
+	l, ok := logger.(*zapLog)
+	if !ok || l.state == nil {
+		return nil
+	}
+	var counters []SamplingCounter
+	for i, o := range l.state.current.Load().outputs {
+		if o.sampling == nil {
+			continue
+		}
+		dropped := make(map[xlog.Level]uint64)
+		for j := range o.sampling.dropped {
+			if n := o.sampling.dropped[j].Load(); n > 0 {
+				dropped[zapLevelToLevel[TraceLevel+zapcore.Level(j)]] = n
+			}
+		}
+		counters = append(counters, SamplingCounter{
+			Index:   i,
+			Name:    o.name,
+			Writer:  o.writer,
+			Dropped: dropped,
+		})
+	}
+	return counters
+}
+
+// samplingCounter counts the logs dropped by sampling of an output.
+type samplingCounter struct {
+	dropped [OffLevel - TraceLevel]atomic.Uint64
+}
+
+// hook is the zapcore.SamplerHook counting dropped logs.
+func (c *samplingCounter) hook(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+	if dec&zapcore.LogDropped != 0 && ent.Level >= TraceLevel && ent.Level < OffLevel {
+		c.dropped[ent.Level-TraceLevel].Add(1)
+	}
+}
+
+// newSampledCore wraps core with samplers of the sampling config, whose
+// dropped logs are counted by counter. Error and higher levels are only
+// sampled by their own policies in cfg.Levels. Levels with the same policy
+// share a sampler, which counts logs by level, since each sampler allocates
+// its counters for all levels.
+func newSampledCore(core zapcore.Core, cfg xlog.SamplingConfig, counter *samplingCounter) zapcore.Core {
+	tick := cfg.Tick
+	if tick == 0 {
+		tick = defaultSamplingTick
+	}
+	policies := make(map[zapcore.Level]xlog.SamplingPolicy, len(cfg.Levels))
+	for name, p := range cfg.Levels {
+		if lv, err := xlog.ParseLevel(name); err == nil {
+			policies[levelToZapLevel[lv]] = p
+		}
+	}
+	samplers := make(map[xlog.SamplingPolicy]zapcore.Core)
+	c := &sampledCore{Core: core}
+	for i := range c.cores {
+		lvl := TraceLevel + zapcore.Level(i)
+		policy, ok := policies[lvl]
+		if !ok && lvl < zapcore.ErrorLevel {
+			policy = cfg.Policy()
+		}
+		if !policy.Enabled() {
+			continue
+		}
+		if lvl == TraceLevel {
+			// Samplers only sample levels from debug, so trace logs are
+			// sampled as debug logs by a sampler of their own.
+			hook := func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+				ent.Level = TraceLevel
+				counter.hook(ent, dec)
+			}
+			c.cores[i] = zapcore.NewSamplerWithOptions(traceAsDebugCore{core}, tick,
+				policy.Initial, policy.Thereafter, zapcore.SamplerHook(hook))
+			continue
+		}
+		sampler, ok := samplers[policy]
+		if !ok {
+			sampler = zapcore.NewSamplerWithOptions(core, tick,
+				policy.Initial, policy.Thereafter, zapcore.SamplerHook(counter.hook))
+			samplers[policy] = sampler
+		}
+		c.cores[i] = sampler
+	}
+	return c
+}
+
+// sampledCore routes logs to the samplers of their levels. Levels without
+// samplers are checked by the embedded core.
+type sampledCore struct {
+	zapcore.Core
+	cores [OffLevel - TraceLevel]zapcore.Core
+}
+
+// With implements zapcore.Core.
+func (c *sampledCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &sampledCore{Core: c.Core.With(fields)}
+	for i, core := range c.cores {
+		if core != nil {
+			clone.cores[i] = core.With(fields)
+		}
+	}
+	return clone
+}
+
+// Check implements zapcore.Core.
+func (c *sampledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < TraceLevel || ent.Level >= OffLevel || c.cores[ent.Level-TraceLevel] == nil {
+		return c.Core.Check(ent, ce)
+	}
+	core := c.cores[ent.Level-TraceLevel]
+	if ent.Level == TraceLevel {
+		ent.Level = zapcore.DebugLevel
+	}
+	return core.Check(ent, ce)
+}
+
+// traceAsDebugCore checks debug logs as trace logs, see newSampledCore.
+type traceAsDebugCore struct {
+	zapcore.Core
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c traceAsDebugCore) Enabled(lvl zapcore.Level) bool {
+	if lvl == zapcore.DebugLevel {
+		lvl = TraceLevel
+	}
+	return c.Core.Enabled(lvl)
+}
+
+// With implements zapcore.Core.
+func (c traceAsDebugCore) With(fields []zapcore.Field) zapcore.Core {
+	return traceAsDebugCore{c.Core.With(fields)}
+}
+
+// Check implements zapcore.Core.
+func (c traceAsDebugCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ent.Level = TraceLevel
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,141 @@
+package zap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampling(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{
+		Name:      "sampling-out",
+		Writer:    testWriter,
+		Formatter: xlog.FormatterJSON,
+		Level:     "trace",
+		Sampling: xlog.SamplingConfig{
+			Tick:       time.Hour,
+			Initial:    2,
+			Thereafter: 3,
+			Levels: map[string]xlog.SamplingPolicy{
+				"error": {},
+				"trace": {Initial: 1},
+			},
+		},
+	}})
+	xlog.SetTraceEnabledFor("sampling", true)
+	t.Cleanup(func() { xlog.ResetTraceEnabledFor("sampling") })
+	named := l.Named("sampling")
+
+	for i := 0; i < 10; i++ {
+		named.Info("same")
+		named.Error("error")
+		named.Trace("trace")
+	}
+	named.Info("other")
+
+	counts := make(map[string]int)
+	for _, e := range testEntries(t, "sampling-out") {
+		counts[e["M"].(string)]++
+		if e["M"] == "trace" && e["L"] != "TRACE" {
+			t.Errorf("sampled trace log level = %v, want TRACE", e["L"])
+		}
+	}
+	// Info logs: the first 2, then every 3rd one, which are the 5th and 8th.
+	want := map[string]int{"same": 4, "error": 10, "trace": 1, "other": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("written = %v, want %v", counts, want)
+	}
+
+	counters := SamplingCounters(l)
+	wantCounters := []SamplingCounter{{
+		Index:   0,
+		Name:    "sampling-out",
+		Writer:  testWriter,
+		Dropped: map[xlog.Level]uint64{xlog.LevelInfo: 6, xlog.LevelTrace: 9},
+	}}
+	if !reflect.DeepEqual(counters, wantCounters) {
+		t.Errorf("counters = %+v, want %+v", counters, wantCounters)
+	}
+}
+
+func TestSamplingCountersWithoutSampling(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{Name: "no-sampling-out", Writer: testWriter}})
+	if counters := SamplingCounters(l); counters != nil {
+		t.Errorf("counters = %+v, want none", counters)
+	}
+	if counters := SamplingCounters(xlog.NewSlogLogger(nil)); counters != nil {
+		t.Errorf("counters of a non-zap logger = %+v, want none", counters)
+	}
+}
+
+func TestSamplingExemptsErrorsByDefault(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels map[string]xlog.SamplingPolicy
+		want   map[string]int
+	}{
+		{name: "default", want: map[string]int{"info": 1, "warn": 1, "error": 5}},
+		{
+			name:   "error-policy",
+			levels: map[string]xlog.SamplingPolicy{"error": {Initial: 2}},
+			want:   map[string]int{"info": 1, "warn": 1, "error": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := "sampling-exempt-" + tt.name
+			l := newTestLog(t, xlog.Config{{
+				Name:      out,
+				Writer:    testWriter,
+				Formatter: xlog.FormatterJSON,
+				Sampling:  xlog.SamplingConfig{Tick: time.Hour, Initial: 1, Levels: tt.levels},
+			}})
+			for i := 0; i < 5; i++ {
+				l.Info("info")
+				l.Warn("warn")
+				l.Error("error")
+			}
+			counts := make(map[string]int)
+			for _, e := range testEntries(t, out) {
+				counts[e["M"].(string)]++
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("written = %v, want %v", counts, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamplingSharesSamplers(t *testing.T) {
+	core := newSampledCore(zapcore.NewNopCore(), xlog.SamplingConfig{
+		Initial: 1,
+		Levels: map[string]xlog.SamplingPolicy{
+			"debug": {Initial: 5},
+			// The same as the default policy.
+			"error": {Initial: 1},
+		},
+	}, &samplingCounter{}).(*sampledCore)
+
+	at := func(lvl zapcore.Level) zapcore.Core {
+		return core.cores[lvl-TraceLevel]
+	}
+	for _, lvl := range []zapcore.Level{TraceLevel, zapcore.DebugLevel, zapcore.InfoLevel, zapcore.ErrorLevel} {
+		if at(lvl) == nil {
+			t.Errorf("%v not sampled", lvl)
+		}
+	}
+	if at(zapcore.InfoLevel) != at(zapcore.WarnLevel) || at(zapcore.InfoLevel) != at(zapcore.ErrorLevel) {
+		t.Error("levels with the same policy do not share a sampler")
+	}
+	if at(zapcore.DebugLevel) == at(zapcore.InfoLevel) || at(TraceLevel) == at(zapcore.DebugLevel) {
+		t.Error("levels with other policies share a sampler")
+	}
+	for _, lvl := range []zapcore.Level{zapcore.DPanicLevel, zapcore.PanicLevel, zapcore.FatalLevel} {
+		if at(lvl) != nil {
+			t.Errorf("%v sampled without a policy of its own", lvl)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package zap
+
+import (
+	"strings"
+	"testing"
+
+	xlog "github.com/oyogames2023/zeus-log"
+)
+
+func TestSetupFromBytesUnits(t *testing.T) {
+	err := xlog.SetupFromBytes([]byte(`
+log:
+  setup-units:
+    - writer: file
+      writer_config:
+        log_path: ` + t.TempDir() + `
+        file_name: units.log
+        write_mode: sync
+        max_size: 100MB
+        max_age: 7d
+`))
+	if err != nil {
+		t.Fatalf("SetupFromBytes: %v", err)
+	}
+	l := xlog.Get("setup-units").(*zapLog)
+	t.Cleanup(func() { _ = l.Close() })
+	wc := l.state.current.Load().cfg[0].WriterConfig
+	if wc.MaxSize != 100 || wc.MaxAge != 7 {
+		t.Errorf("max_size = %d, max_age = %d, want 100 and 7", wc.MaxSize, wc.MaxAge)
+	}
+}
+
+func TestSetupFromBytesDuplicateLogger(t *testing.T) {
+	doc := []byte(`
+log:
+  setup-dup:
+    - name: setup-dup-out
+      writer: test
+`)
+	if err := xlog.SetupFromBytes(doc); err != nil {
+		t.Fatalf("SetupFromBytes: %v", err)
+	}
+	err := xlog.SetupFromBytes(doc)
+	if err == nil || !strings.Contains(err.Error(), "setup-dup already registered") {
+		t.Fatalf("second SetupFromBytes err = %v, want already registered", err)
+	}
+	if bufs := testOutput("setup-dup-out"); len(bufs) != 1 {
+		t.Errorf("output set up %d times, want once", len(bufs))
+	}
+}
+
+func TestFactorySetupDuplicateClosesLogger(t *testing.T) {
+	cfg := xlog.Config{{Name: "factory-dup-out", Writer: testWriter}}
+	if err := DefaultFactory.Setup("factory-dup", &configDecoder{cfg}); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if err := DefaultFactory.Setup("factory-dup", &configDecoder{cfg}); err == nil {
+		t.Fatal("second Setup succeeded, want error")
+	}
+	bufs := testOutput("factory-dup-out")
+	if len(bufs) != 2 {
+		t.Fatalf("output set up %d times, want twice", len(bufs))
+	}
+	if bufs[0].isClosed() || !bufs[1].isClosed() {
+		t.Errorf("closed = %v, %v, want only the second one closed", bufs[0].isClosed(), bufs[1].isClosed())
+	}
+}
+
+// configDecoder decodes a Config as is.
+type configDecoder struct {
+	cfg xlog.Config
+}
+
+func (d *configDecoder) Decode(cfg any) error {
+	*cfg.(*xlog.Config) = d.cfg
+	return nil
+}
+
+func TestSetupFromBytesErrorLines(t *testing.T) {
+	t.Setenv("ZEUS_LOG_LEVEL_SETUP_LINES", "warn")
+	err := xlog.SetupFromBytes([]byte(`
+server:
+  port: 80
+
+log:
+  setup-lines:
+    - writer: console
+    - writer: file
+      levle: debug
+      writer_config:
+        file_name: a.log
+        max_sizee: 10MB
+`))
+	if err == nil {
+		t.Fatal("SetupFromBytes succeeded, want unknown field errors")
+	}
+	for _, want := range []string{
+		"line 9: field levle not found in type zeus_log.OutputConfig",
+		"line 12: field max_sizee not found in type zeus_log.WriterConfig",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package zap
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+)
+
+// middlewareHandler wraps a handler like logging middlewares do, which adds
+// frames between slog.Logger methods and the wrapped handler.
+type middlewareHandler struct {
+	slog.Handler
+}
+
+func (h middlewareHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.String("mw", "1"))
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestSlogHandlerCallerThroughMiddleware(t *testing.T) {
+	l, logs := newObservedLog(2)
+	logger := slog.New(middlewareHandler{xlog.NewSlogHandler(l)})
+	logger.Info("wrapped", "k", 1)
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	want := "github.com/oyogames2023/zeus-log/log/zap.TestSlogHandlerCallerThroughMiddleware"
+	if got := entries[0].Caller.Function; got != want {
+		t.Errorf("caller = %s, want %s", got, want)
+	}
+	if got := entries[0].ContextMap()["mw"]; got != "1" {
+		t.Errorf("mw = %v, want 1", got)
+	}
+}
+
+func TestSlogHandlerHandleRecord(t *testing.T) {
+	l, logs := newObservedLog(2)
+	h := xlog.NewSlogHandler(l)
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := h.Handle(context.Background(), slog.NewRecord(at, slog.LevelWarn, "direct", pcs[0])); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Handle(context.Background(), slog.NewRecord(at, slog.LevelWarn, "no pc", 0)); err != nil {
+		t.Fatal(err)
+	}
+	entries := logs.TakeAll()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if !entries[0].Time.Equal(at) {
+		t.Errorf("time = %v, want %v", entries[0].Time, at)
+	}
+	want := "github.com/oyogames2023/zeus-log/log/zap.TestSlogHandlerHandleRecord"
+	if got := entries[0].Caller.Function; got != want {
+		t.Errorf("caller = %s, want %s", got, want)
+	}
+	if entries[1].Caller.Defined {
+		t.Errorf("caller = %v, want undefined for zero pc", entries[1].Caller)
+	}
+}
@@ -0,0 +1,227 @@
+package zap
+
+import (
+	"fmt"
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+const (
+	// defaultStacktraceDepth is the max number of frames of stack traces if
+	// it's not configured.
+	defaultStacktraceDepth = 32
+	// stackRequested is set as the stack of entries by stackLevelCore, asking
+	// the outputs to attach stack traces.
+	stackRequested = "\x00stack"
+)
+
+// libraryPrefixes are the prefixes of the functions of zeus-log, zap and the
+// runtime, which are trimmed from stack traces.
+var libraryPrefixes = func() []string {
+	modulePath := reflect.TypeOf(xlog.Field{}).PkgPath()
+	return []string{
+		"runtime.",
+		"go.uber.org/zap",
+		modulePath + ".",
+		modulePath + "/log/",
+		modulePath + "/plugin.",
+		modulePath + "/rollwriter.",
+	}
+}()
+
+// stackCore trims the stack traces of the logs of an output by the output
+// config. Stacks are captured once per log by reloadableCore, as deep as the
+// deepest output wants them, and formatted by the formatters of the outputs.
+type stackCore struct {
+	zapcore.Core
+	// level is the level from which stack traces are attached.
+	level zapcore.Level
+	depth int
+	// captureLevel is the level from which stacks are captured for any
+	// output of the logger, see loggerState.stackLevel.
+	captureLevel zapcore.Level
+	// noStack writes logs with the stacks captured for other outputs removed.
+	noStack zapcore.Core
+}
+
+// newStackCore wraps core to attach stack traces by the output config. The
+// captureLevel of it is set once all outputs are built.
+func newStackCore(core zapcore.Core, c *xlog.OutputConfig) *stackCore {
+	sc := &stackCore{
+		Core:         core,
+		level:        OffLevel,
+		depth:        c.StacktraceDepth,
+		captureLevel: OffLevel,
+		noStack:      noStackCore{Core: core},
+	}
+	if c.StacktraceLevel != "" {
+		sc.level = getZapLevel(c.StacktraceLevel)
+	}
+	if sc.depth == 0 {
+		sc.depth = defaultStacktraceDepth
+	}
+	return sc
+}
+
+// With implements zapcore.Core.
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	clone.noStack = noStackCore{Core: clone.Core}
+	return &clone
+}
+
+// Check implements zapcore.Core. Logs without stack traces are checked by the
+// wrapped core.
+func (c *stackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	switch {
+	case c.wantStack(ent):
+	case ent.Level >= c.captureLevel:
+		if c.Enabled(ent.Level) {
+			return ce.AddCore(ent, c.noStack)
+		}
+		return ce
+	default:
+		return c.Core.Check(ent, ce)
+	}
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// wantStack reports whether a stack trace is attached to ent.
+func (c *stackCore) wantStack(ent zapcore.Entry) bool {
+	return ent.Level >= c.level || ent.Stack == stackRequested
+}
+
+// Write implements zapcore.Core.
+func (c *stackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Stack == stackRequested {
+		ent.Stack = ""
+	}
+	ent.Stack = trimStack(ent.Stack, c.depth)
+	return c.Core.Write(ent, fields)
+}
+
+// noStackCore writes logs without stack traces.
+type noStackCore struct {
+	zapcore.Core
+}
+
+// Write implements zapcore.Core.
+func (c noStackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Stack = ""
+	return c.Core.Write(ent, fields)
+}
+
+// trimStack returns the first depth frames of stack, see captureStack.
+func trimStack(stack string, depth int) string {
+	// Every frame takes two lines.
+	lines := 0
+	for i := 0; i < len(stack); i++ {
+		if stack[i] == '\n' {
+			if lines++; lines == 2*depth {
+				return stack[:i]
+			}
+		}
+	}
+	return stack
+}
+
+// captureStack returns at most depth frames of the current stack, from the
+// first one outside zeus-log, zap and the runtime. They are formatted like the
+// stacks of zap, every frame is the function on a line followed by the file
+// and line indented by a tab on the next line.
+func captureStack(depth int) string {
+	// The frames of zeus-log and zap are less than 32.
+	pcs := make([]uintptr, depth+32)
+	n := runtime.Callers(2, pcs)
+	it := runtime.CallersFrames(pcs[:n])
+	var sb strings.Builder
+	for frames := 0; frames < depth; {
+		f, more := it.Next()
+		if f.Function == "runtime.goexit" {
+			break
+		}
+		if frames > 0 || !isLibraryFrame(f.Function) {
+			if frames > 0 {
+				sb.WriteByte('\n')
+			}
+			fmt.Fprintf(&sb, "%s\n\t%s:%d", f.Function, f.File, f.Line)
+			frames++
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// stackFrames returns the frames of stack, see captureStack, each of which is
+// the function followed by the file and line.
+func stackFrames(stack string) []string {
+	lines := strings.Split(stack, "\n")
+	frames := make([]string, 0, len(lines)/2)
+	for i := 0; i+1 < len(lines); i += 2 {
+		frames = append(frames, lines[i]+" "+strings.TrimPrefix(lines[i+1], "\t"))
+	}
+	return frames
+}
+
+// stackArrayEncoder encodes the stack traces of entries as arrays of frames,
+// under the stack trace key.
+type stackArrayEncoder struct {
+	zapcore.Encoder
+	key string
+}
+
+// Clone implements zapcore.Encoder.
+func (e *stackArrayEncoder) Clone() zapcore.Encoder {
+	return &stackArrayEncoder{Encoder: e.Encoder.Clone(), key: e.key}
+}
+
+// EncodeEntry implements zapcore.Encoder.
+func (e *stackArrayEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if ent.Stack != "" {
+		fields = append(fields[:len(fields):len(fields)], zap.Strings(e.key, stackFrames(ent.Stack)))
+		ent.Stack = ""
+	}
+	return e.Encoder.EncodeEntry(ent, fields)
+}
+
+// isLibraryFrame reports whether the function is in zeus-log, zap or the
+// runtime.
+func isLibraryFrame(function string) bool {
+	for _, prefix := range libraryPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackLevelCore requests stack traces of logs from level on, see
+// xlog.WithStacktraceLevel.
+type stackLevelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+// With implements zapcore.Core.
+func (c *stackLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackLevelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+// Check implements zapcore.Core.
+func (c *stackLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= c.level {
+		ent.Stack = stackRequested
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,134 @@
+package zap
+
+import (
+	"strings"
+	"testing"
+
+	xlog "github.com/oyogames2023/zeus-log"
+)
+
+// logFromStdlib calls f from the standard library, whose frames are kept in
+// stack traces unlike the ones of this package.
+func logFromStdlib(f func()) {
+	strings.Map(func(r rune) rune {
+		f()
+		return r
+	}, "x")
+}
+
+func stackConfig() xlog.Config {
+	return xlog.Config{
+		{
+			Name:            "stack-array-out",
+			Writer:          testWriter,
+			Formatter:       xlog.FormatterJSON,
+			StacktraceLevel: "warn",
+			StacktraceDepth: 2,
+			FormatConfig: xlog.FormatConfig{
+				StacktraceKey:    "stack",
+				StacktraceFormat: xlog.StacktraceFormatArray,
+			},
+		},
+		{
+			Name:            "stack-string-out",
+			Writer:          testWriter,
+			Formatter:       xlog.FormatterJSON,
+			StacktraceLevel: "error",
+		},
+		{
+			Name:            "stack-console-out",
+			Writer:          testWriter,
+			StacktraceLevel: "error",
+		},
+	}
+}
+
+func TestStacktraceLevelAndFormat(t *testing.T) {
+	l := newTestLog(t, stackConfig())
+	logFromStdlib(func() {
+		l.Info("info")
+		l.Warn("warn")
+		l.Error("error")
+	})
+
+	arrays := testEntries(t, "stack-array-out")
+	if len(arrays) != 3 {
+		t.Fatalf("array output entries = %v, want 3", arrays)
+	}
+	if _, ok := arrays[0]["stack"]; ok {
+		t.Errorf("info log has stack: %v", arrays[0])
+	}
+	for _, e := range arrays[1:] {
+		frames, ok := e["stack"].([]any)
+		// The frames of this package are trimmed, and the depth is 2.
+		if !ok || len(frames) != 2 {
+			t.Errorf("stack of %s = %v, want 2 frames", e["M"], e["stack"])
+			continue
+		}
+		first, second := frames[0].(string), frames[1].(string)
+		if !strings.HasPrefix(first, "strings.Map ") || !strings.Contains(first, ".go:") {
+			t.Errorf("first frame = %q, want strings.Map with its file", first)
+		}
+		if !strings.HasSuffix(strings.Fields(second)[0], ".logFromStdlib") {
+			t.Errorf("second frame = %q, want logFromStdlib", second)
+		}
+		if _, ok := e["S"]; ok {
+			t.Errorf("stack also encoded as string: %v", e)
+		}
+	}
+
+	strs := testEntries(t, "stack-string-out")
+	if len(strs) != 3 {
+		t.Fatalf("string output entries = %v, want 3", strs)
+	}
+	for _, e := range strs[:2] {
+		if _, ok := e["S"]; ok {
+			t.Errorf("%s log has stack: %v", e["M"], e)
+		}
+	}
+	stack, _ := strs[2]["S"].(string)
+	lines := strings.Split(stack, "\n")
+	// strings.Map, logFromStdlib, the test and testing.tRunner.
+	if len(lines) != 8 || !strings.HasPrefix(lines[0], "strings.Map") ||
+		!strings.HasPrefix(lines[1], "\t") || !strings.HasPrefix(lines[6], "testing.tRunner") {
+		t.Errorf("error log stack = %q, want 4 frames from strings.Map", stack)
+	}
+
+	console := testLines(t, "stack-console-out")
+	if len(console) != 3+len(lines) {
+		t.Fatalf("console lines = %q, want 3 logs and the stack lines", console)
+	}
+	if !strings.Contains(console[2], "error") || console[3] != lines[0] || console[4] != lines[1] {
+		t.Errorf("console lines = %q, want the stack after the error log", console)
+	}
+}
+
+func TestStacktraceRequestedByOption(t *testing.T) {
+	l := newTestLog(t, stackConfig())
+	logFromStdlib(func() {
+		l.WithOptions(xlog.WithStacktraceLevel(xlog.LevelInfo)).Info("info")
+	})
+	if e := testEntries(t, "stack-array-out")[0]; len(e["stack"].([]any)) != 2 {
+		t.Errorf("array output stack = %v, want 2 frames", e["stack"])
+	}
+	if e := testEntries(t, "stack-string-out")[0]; !strings.HasPrefix(e["S"].(string), "strings.Map\n\t") {
+		t.Errorf("string output stack = %v, want from strings.Map", e["S"])
+	}
+}
+
+func TestTrimStack(t *testing.T) {
+	stack := "a\n\ta.go:1\nb\n\tb.go:2\nc\n\tc.go:3"
+	for depth, want := range map[int]string{
+		1: "a\n\ta.go:1",
+		2: "a\n\ta.go:1\nb\n\tb.go:2",
+		3: stack,
+		4: stack,
+	} {
+		if got := trimStack(stack, depth); got != want {
+			t.Errorf("trimStack(%d) = %q, want %q", depth, got, want)
+		}
+	}
+	if got := stackFrames(stack); strings.Join(got, ",") != "a a.go:1,b b.go:2,c c.go:3" {
+		t.Errorf("stackFrames = %q", got)
+	}
+}
@@ -0,0 +1,261 @@
+package zap
+
+import (
+	"fmt"
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultThrottleSummaryInterval is the interval of summaries of shed logs
+	// if it's not configured.
+	defaultThrottleSummaryInterval = 10 * time.Second
+	// entryOverhead is the estimated size of the parts of a log other than its
+	// message and fields, like the time, level and caller.
+	entryOverhead = 64
+	// fieldOverhead is the estimated size of a field whose size is unknown.
+	fieldOverhead = 32
+)
+
+// throttle is the budget of an output, shared by the loggers derived from it.
+type throttle struct {
+	cfg      xlog.ThrottleConfig
+	interval time.Duration
+	// base is the core of the output without context fields, which summaries
+	// are written to.
+	base zapcore.Core
+
+	mu sync.Mutex
+	// second is the unix second the used budget is counted in.
+	second  int64
+	entries int
+	bytes   int64
+	// shed are the numbers of shed logs by level since shedSince.
+	shed      [OffLevel - TraceLevel]uint64
+	shedSince time.Time
+	// started is set when the goroutine writing summaries is started by the
+	// first shed log, halted when the throttle is stopped, then no goroutine
+	// is started.
+	started bool
+	halted  bool
+
+	// done stops the goroutine writing summaries, which closes stopped.
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// newThrottledCore wraps core with the budget of the throttle config. Once a
+// log is shed, summaries of shed logs are written to core every summary
+// interval until the returned throttle is stopped. The sizes of logs are
+// estimated from their messages and fields before they are encoded, see
+// estimateFieldsSize, so the bytes budget is approximate.
+func newThrottledCore(core zapcore.Core, cfg xlog.ThrottleConfig) (zapcore.Core, *throttle) {
+	interval := cfg.SummaryInterval
+	if interval == 0 {
+		interval = defaultThrottleSummaryInterval
+	}
+	t := &throttle{
+		cfg:      cfg,
+		interval: interval,
+		base:     core,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	return &throttledCore{Core: core, t: t}, t
+}
+
+// run writes the summary of shed logs every summary interval until stopped.
+func (t *throttle) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := t.writeSummary(now); err != nil {
+				fmt.Printf("log: write throttle summary err: %+v\n", err)
+			}
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// stop stops writing summaries periodically, and writes the pending one. No
+// summary is written to the output after it returns.
+func (t *throttle) stop() error {
+	t.mu.Lock()
+	if !t.halted {
+		t.halted = true
+		close(t.done)
+	}
+	started := t.started
+	t.mu.Unlock()
+	if started {
+		<-t.stopped
+	}
+	return t.writeSummary(time.Now())
+}
+
+// levelShare returns the share of the budget logs at lvl may use, as a
+// fraction num/den.
+func levelShare(lvl zapcore.Level) (num, den int64) {
+	switch {
+	case lvl <= zapcore.DebugLevel:
+		return 1, 2
+	case lvl == zapcore.InfoLevel:
+		return 3, 4
+	default:
+		return 1, 1
+	}
+}
+
+// allow reports whether a log of the size fits the budget of its level, and
+// counts it in the used budget or the shed logs.
+func (t *throttle) allow(lvl zapcore.Level, size int64, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if sec := now.Unix(); sec != t.second {
+		t.second, t.entries, t.bytes = sec, 0, 0
+	}
+	if lvl < zapcore.ErrorLevel {
+		num, den := levelShare(lvl)
+		overEntries := t.cfg.EntriesPerSecond > 0 &&
+			int64(t.entries+1)*den > int64(t.cfg.EntriesPerSecond)*num
+		overBytes := t.cfg.BytesPerSecond > 0 &&
+			(t.bytes+size)*den > int64(t.cfg.BytesPerSecond)*num
+		if overEntries || overBytes {
+			if t.shedCount() == 0 {
+				t.shedSince = now
+			}
+			if !t.started && !t.halted {
+				t.started = true
+				go t.run()
+			}
+			if lvl >= TraceLevel {
+				t.shed[lvl-TraceLevel]++
+			}
+			return false
+		}
+	}
+	t.entries++
+	t.bytes += size
+	return true
+}
+
+// shedCount returns the number of shed logs since the last summary.
+func (t *throttle) shedCount() uint64 {
+	var n uint64
+	for _, c := range t.shed {
+		n += c
+	}
+	return n
+}
+
+// writeSummary writes the summary of the logs shed since the last one, if any.
+// Like other warn logs, it's dropped if the output level is above warn.
+func (t *throttle) writeSummary(now time.Time) error {
+	t.mu.Lock()
+	total := t.shedCount()
+	if total == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	shed, since := t.shed, t.shedSince
+	t.shed = [OffLevel - TraceLevel]uint64{}
+	t.mu.Unlock()
+	if !t.base.Enabled(zapcore.WarnLevel) {
+		return nil
+	}
+
+	period := now.Sub(since).Round(time.Millisecond)
+	fields := []zapcore.Field{zap.Duration("period", period)}
+	for i, n := range shed {
+		if n > 0 {
+			lvl := TraceLevel + zapcore.Level(i)
+			fields = append(fields, zap.Uint64("shed_"+zapLevelToLevel[lvl].String(), n))
+		}
+	}
+	ent := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    now,
+		Message: fmt.Sprintf("log throttled: %d logs shed in %s", total, period),
+	}
+	return t.base.Write(ent, fields)
+}
+
+// throttledCore sheds the logs exceeding the budget of the output.
+type throttledCore struct {
+	zapcore.Core
+	t *throttle
+	// fieldsSize is the estimated size of the context fields.
+	fieldsSize int64
+}
+
+// With implements zapcore.Core.
+func (c *throttledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &throttledCore{
+		Core:       c.Core.With(fields),
+		t:          c.t,
+		fieldsSize: c.fieldsSize + estimateFieldsSize(fields),
+	}
+}
+
+// Check implements zapcore.Core. The budget is checked in Write, where the
+// fields of logs are known.
+func (c *throttledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *throttledCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	size := entryOverhead + int64(len(ent.Message)) + c.fieldsSize + estimateFieldsSize(fields)
+	if !c.t.allow(ent.Level, size, time.Now()) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// Sync implements zapcore.Core. The pending summary is written first.
+func (c *throttledCore) Sync() error {
+	summaryErr := c.t.writeSummary(time.Now())
+	if err := c.Core.Sync(); err != nil {
+		return err
+	}
+	return summaryErr
+}
+
+// estimateFieldsSize returns the approximate encoded size of fields.
+func estimateFieldsSize(fields []zapcore.Field) int64 {
+	var size int64
+	for _, f := range fields {
+		size += int64(len(f.Key)) + 4
+		switch f.Type {
+		case zapcore.StringType:
+			size += int64(len(f.String))
+		case zapcore.BinaryType, zapcore.ByteStringType:
+			if b, ok := f.Interface.([]byte); ok {
+				size += int64(len(b))
+			}
+		case zapcore.BoolType, zapcore.Int8Type, zapcore.Int16Type, zapcore.Int32Type, zapcore.Int64Type,
+			zapcore.Uint8Type, zapcore.Uint16Type, zapcore.Uint32Type, zapcore.Uint64Type,
+			zapcore.Float32Type, zapcore.Float64Type, zapcore.DurationType, zapcore.UintptrType:
+			size += 8
+		case zapcore.TimeType, zapcore.TimeFullType:
+			size += 24
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				size += int64(len(err.Error()))
+			}
+		default:
+			size += fieldOverhead
+		}
+	}
+	return size
+}
@@ -0,0 +1,217 @@
+package zap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	xlog "github.com/oyogames2023/zeus-log"
+	"go.uber.org/zap/zapcore"
+)
+
+// newTestThrottle returns a throttle of a nop core, stopped when the test ends.
+func newTestThrottle(t *testing.T, cfg xlog.ThrottleConfig) *throttle {
+	_, th := newThrottledCore(zapcore.NewNopCore(), cfg)
+	t.Cleanup(func() { th.stop() })
+	return th
+}
+
+func TestThrottleShedsLowerLevelsFirst(t *testing.T) {
+	th := newTestThrottle(t, xlog.ThrottleConfig{EntriesPerSecond: 8})
+	now := time.Unix(1000, 0)
+	allowed := make(map[zapcore.Level]int)
+	for _, lvl := range []zapcore.Level{
+		zapcore.DebugLevel, zapcore.DebugLevel, zapcore.DebugLevel, zapcore.DebugLevel, zapcore.DebugLevel,
+		zapcore.InfoLevel, zapcore.InfoLevel, zapcore.InfoLevel,
+		zapcore.WarnLevel, zapcore.WarnLevel, zapcore.WarnLevel,
+		zapcore.ErrorLevel, zapcore.ErrorLevel, zapcore.DPanicLevel,
+		TraceLevel, zapcore.DebugLevel,
+	} {
+		if th.allow(lvl, 0, now) {
+			allowed[lvl]++
+		}
+	}
+	// Debug logs use half of the budget, info logs three quarters, warn logs
+	// all of it, and error logs are never shed.
+	want := map[zapcore.Level]int{
+		zapcore.DebugLevel:  4,
+		zapcore.InfoLevel:   2,
+		zapcore.WarnLevel:   2,
+		zapcore.ErrorLevel:  2,
+		zapcore.DPanicLevel: 1,
+	}
+	for lvl, n := range want {
+		if allowed[lvl] != n {
+			t.Errorf("allowed %s logs = %d, want %d", lvl, allowed[lvl], n)
+		}
+	}
+	if allowed[TraceLevel] != 0 {
+		t.Errorf("allowed trace logs = %d, want 0", allowed[TraceLevel])
+	}
+	if n := th.shedCount(); n != 5 {
+		t.Errorf("shed = %d, want 5", n)
+	}
+	if !th.shedSince.Equal(now) {
+		t.Errorf("shedSince = %v, want %v", th.shedSince, now)
+	}
+
+	if !th.allow(zapcore.DebugLevel, 0, now.Add(time.Second)) {
+		t.Error("debug log shed in a new second")
+	}
+}
+
+func TestThrottleBytesBudget(t *testing.T) {
+	th := newTestThrottle(t, xlog.ThrottleConfig{BytesPerSecond: 100})
+	now := time.Unix(1000, 0)
+	if !th.allow(zapcore.DebugLevel, 40, now) {
+		t.Error("first debug log shed")
+	}
+	if th.allow(zapcore.DebugLevel, 40, now) {
+		t.Error("debug log over half of the budget not shed")
+	}
+	if !th.allow(zapcore.InfoLevel, 30, now) {
+		t.Error("info log within three quarters of the budget shed")
+	}
+	if !th.allow(zapcore.ErrorLevel, 1000, now) {
+		t.Error("error log over the budget shed")
+	}
+}
+
+func TestThrottleSummaryInterval(t *testing.T) {
+	// The budget of 1 log per second sheds every debug and info log.
+	l := newTestLog(t, xlog.Config{{
+		Name:      "throttle-out",
+		Writer:    testWriter,
+		Formatter: xlog.FormatterJSON,
+		Level:     "debug",
+		Throttle: xlog.ThrottleConfig{
+			EntriesPerSecond: 1,
+			SummaryInterval:  10 * time.Millisecond,
+		},
+	}})
+	l.Debug("debug")
+	l.Debug("debug")
+	l.Info("info")
+	l.Info("info")
+	l.Info("info")
+	l.Error("error")
+
+	// The summaries are written by the ticker, without further logs. The
+	// shed logs may be split over several summaries.
+	shed := make(map[string]float64)
+	var written []string
+	deadline := time.Now().Add(5 * time.Second)
+	for shed["shed_debug"]+shed["shed_info"] < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		shed = make(map[string]float64)
+		written = nil
+		for _, e := range testEntries(t, "throttle-out") {
+			msg := e["M"].(string)
+			if !strings.HasPrefix(msg, "log throttled: ") {
+				written = append(written, msg)
+				continue
+			}
+			if e["L"] != "WARN" {
+				t.Errorf("summary level = %v, want WARN", e["L"])
+			}
+			if _, ok := e["period"]; !ok {
+				t.Errorf("summary %v has no period", e)
+			}
+			for _, key := range []string{"shed_debug", "shed_info"} {
+				if n, ok := e[key].(float64); ok {
+					shed[key] += n
+				}
+			}
+		}
+	}
+	if shed["shed_debug"] != 2 || shed["shed_info"] != 3 {
+		t.Errorf("shed in summaries = %v, want 2 debug and 3 info logs", shed)
+	}
+	if len(written) != 1 || written[0] != "error" {
+		t.Errorf("written logs = %q, want only the error one", written)
+	}
+}
+
+func TestThrottleSummaryOnClose(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{
+		Name:      "throttle-close-out",
+		Writer:    testWriter,
+		Formatter: xlog.FormatterJSON,
+		Throttle: xlog.ThrottleConfig{
+			EntriesPerSecond: 1,
+			SummaryInterval:  time.Hour,
+		},
+	}})
+	l.Info("info")
+	l.Info("info")
+	if lines := testLines(t, "throttle-close-out"); len(lines) != 0 {
+		t.Fatalf("lines before close = %q, want none", lines)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	entries := testEntries(t, "throttle-close-out")
+	if len(entries) != 1 {
+		t.Fatalf("entries = %v, want the summary", entries)
+	}
+	if msg := entries[0]["M"].(string); !strings.HasPrefix(msg, "log throttled: 2 logs shed in ") {
+		t.Errorf("summary = %q", msg)
+	}
+	if n := entries[0]["shed_info"]; n != float64(2) {
+		t.Errorf("shed_info = %v, want 2", n)
+	}
+}
+
+func TestThrottleStartsOnFirstShedLog(t *testing.T) {
+	th := newTestThrottle(t, xlog.ThrottleConfig{EntriesPerSecond: 1})
+	now := time.Unix(1000, 0)
+	started := func() bool {
+		th.mu.Lock()
+		defer th.mu.Unlock()
+		return th.started
+	}
+	th.allow(zapcore.WarnLevel, 0, now)
+	if started() {
+		t.Error("summaries started before a log is shed")
+	}
+	th.allow(zapcore.WarnLevel, 0, now)
+	if !started() {
+		t.Error("summaries not started by the shed log")
+	}
+
+	// A stopped throttle starts no goroutine.
+	stopped := newTestThrottle(t, xlog.ThrottleConfig{EntriesPerSecond: 1})
+	if err := stopped.stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	stopped.allow(zapcore.WarnLevel, 0, now)
+	stopped.allow(zapcore.WarnLevel, 0, now)
+	if stopped.started {
+		t.Error("summaries started after stop")
+	}
+}
+
+func TestThrottleSummaryFollowsOutputLevel(t *testing.T) {
+	l := newTestLog(t, xlog.Config{{
+		Name:      "throttle-level-out",
+		Writer:    testWriter,
+		Formatter: xlog.FormatterJSON,
+		Throttle: xlog.ThrottleConfig{
+			EntriesPerSecond: 2,
+			SummaryInterval:  time.Hour,
+		},
+	}})
+	l.Info("info")
+	l.Info("info")
+	if err := l.SetLevel("throttle-level-out", xlog.LevelError); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// The summary is a warn log, dropped at level error.
+	entries := testEntries(t, "throttle-level-out")
+	if len(entries) != 1 || entries[0]["M"] != "info" {
+		t.Errorf("entries = %v, want only the first info log", entries)
+	}
+}
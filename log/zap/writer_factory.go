@@ -29,7 +29,11 @@ func (f *ConsoleWriterFactory) Setup(name string, dec plugin.Decoder) error {
 	if err := decoder.Decode(&cfg); err != nil {
 		return err
 	}
-	decoder.Core, decoder.ZapLevel = newConsoleCore(cfg)
+	core, level, err := newConsoleCore(cfg)
+	if err != nil {
+		return err
+	}
+	decoder.Core, decoder.ZapLevel = core, level
 	return nil
 }
 
@@ -70,10 +74,10 @@ func (f *FileWriterFactory) setupConfig(decoder *Decoder) error {
 		cfg.WriterConfig.RollType = xlog.GetRollingType(xlog.RollingBySize)
 	}
 
-	core, level, err := newFileCore(cfg)
+	core, level, closer, err := newFileCore(cfg)
 	if err != nil {
 		return err
 	}
-	decoder.Core, decoder.ZapLevel = core, level
+	decoder.Core, decoder.ZapLevel, decoder.Closer = core, level, closer
 	return nil
 }
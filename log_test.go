@@ -0,0 +1,183 @@
+package zeus_log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+)
+
+// recordHandler is a slog.Handler which keeps the handled records.
+type recordHandler struct {
+	mu      *sync.Mutex
+	records *[]slog.Record
+	attrs   []slog.Attr
+	groups  []string
+}
+
+func newRecordHandler() *recordHandler {
+	return &recordHandler{mu: &sync.Mutex{}, records: new([]slog.Record)}
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r = r.Clone()
+	r.AddAttrs(h.attrs...)
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+func (h *recordHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	return &clone
+}
+
+// messages returns the messages of the handled records.
+func (h *recordHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msgs := make([]string, len(*h.records))
+	for i, r := range *h.records {
+		msgs[i] = r.Message
+	}
+	return msgs
+}
+
+// last returns the last handled record.
+func (h *recordHandler) last(t *testing.T) slog.Record {
+	t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(*h.records) == 0 {
+		t.Fatal("no record handled")
+	}
+	return (*h.records)[len(*h.records)-1]
+}
+
+// recordFunction returns the function of the caller of r.
+func recordFunction(r slog.Record) string {
+	f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return f.Function
+}
+
+// setTestDefaultLogger replaces the default Logger until the test ends.
+func setTestDefaultLogger(t *testing.T, l Logger) {
+	prev := GetDefaultLogger()
+	SetLogger(l)
+	t.Cleanup(func() { SetLogger(prev) })
+}
+
+func TestContextLogger(t *testing.T) {
+	def, carried := newRecordHandler(), newRecordHandler()
+	setTestDefaultLogger(t, NewSlogLogger(def))
+	// NewContext takes a logger for direct use.
+	direct := NewSlogLogger(carried).(OptionLogger).WithOptions(WithAdditionalCallerSkip(-1))
+	ctx := NewContext(context.Background(), direct.With("req", 1))
+	want := []string{"package", "direct", "with", "with fields"}
+
+	InfoContext(ctx, "package")
+	FromContext(ctx).Info("direct")
+	WithContext(ctx, "k", "v").Info("with")
+	WithFieldsContext(ctx, String("k", "v")).Info("with fields")
+
+	if got := carried.messages(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("carried logger messages = %q, want %q", got, want)
+	}
+	for i, r := range *carried.records {
+		if fn := recordFunction(r); !strings.HasSuffix(fn, ".TestContextLogger") {
+			t.Errorf("caller of %s = %s, want the test", want[i], fn)
+		}
+	}
+	if got := def.messages(); len(got) != 0 {
+		t.Errorf("default logger messages = %q, want none", got)
+	}
+}
+
+func TestContextLoggerFallsBackToDefault(t *testing.T) {
+	def := newRecordHandler()
+	setTestDefaultLogger(t, NewSlogLogger(def))
+	for _, ctx := range []context.Context{context.Background(), nil} {
+		InfoContext(ctx, "package")
+		if fn := recordFunction(def.last(t)); !strings.HasSuffix(fn, ".TestContextLoggerFallsBackToDefault") {
+			t.Errorf("package function caller = %s, want the test", fn)
+		}
+		FromContext(ctx).Info("direct")
+		if fn := recordFunction(def.last(t)); !strings.HasSuffix(fn, ".TestContextLoggerFallsBackToDefault") {
+			t.Errorf("direct caller = %s, want the test", fn)
+		}
+		WithContext(ctx, "k", "v").Info("with")
+		WithFieldsContext(ctx, String("k", "v")).Info("with fields")
+	}
+	want := "package,direct,with,with fields,package,direct,with,with fields"
+	if got := def.messages(); strings.Join(got, ",") != want {
+		t.Errorf("default logger messages = %q, want %s", got, want)
+	}
+
+	ctx := NewContext(nil, NewSlogLogger(def))
+	if l, ok := contextLogger(ctx); !ok || l == nil {
+		t.Error("logger not carried by the context derived from nil")
+	}
+}
+
+func TestFromContextWithoutLoggerDoesNotAllocate(t *testing.T) {
+	setTestDefaultLogger(t, NewSlogLogger(newRecordHandler()))
+	ctx := context.Background()
+	first := FromContext(ctx)
+	if allocs := testing.AllocsPerRun(100, func() { FromContext(ctx) }); allocs != 0 {
+		t.Errorf("FromContext allocs = %v, want 0", allocs)
+	}
+	if FromContext(ctx) != first {
+		t.Error("FromContext returned another logger for the same default one")
+	}
+
+	// The cache follows the default logger.
+	h := newRecordHandler()
+	SetLogger(NewSlogLogger(h))
+	FromContext(ctx).Info("new default")
+	if got := h.messages(); len(got) != 1 {
+		t.Errorf("new default logger messages = %q, want 1", got)
+	}
+}
+
+func TestPackageLevels(t *testing.T) {
+	setTestDefaultLogger(t, NewSlogLogger(newRecordHandler()))
+
+	SetLevel(AllOutputs, LevelWarn)
+	if got := GetLevel(slogOutput); got != LevelWarn {
+		t.Errorf("GetLevel = %v, want WARN", got)
+	}
+	// Unknown outputs are ignored by SetLevel and reported by SetOutputLevel.
+	SetLevel("file", LevelDebug)
+	if got := GetLevel("file"); got != LevelOff {
+		t.Errorf("GetLevel of an unknown output = %v, want OFF", got)
+	}
+	if err := SetOutputLevel("file", LevelDebug); !errors.Is(err, ec.ErrUnknownOutput) {
+		t.Errorf("SetOutputLevel err = %v, want ErrUnknownOutput", err)
+	}
+	if _, err := GetOutputLevel("file"); !errors.Is(err, ec.ErrUnknownOutput) {
+		t.Errorf("GetOutputLevel err = %v, want ErrUnknownOutput", err)
+	}
+	if err := SetOutputLevel("", LevelError); err != nil {
+		t.Fatalf("SetOutputLevel: %v", err)
+	}
+	if level, err := GetOutputLevel(""); err != nil || level != LevelError {
+		t.Errorf("GetOutputLevel = %v, %v, want ERROR", level, err)
+	}
+}
@@ -1,6 +1,15 @@
 package zeus_log
 
-import "io"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+	yaml "gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"time"
+)
 
 type Level int
 
@@ -16,13 +25,85 @@ const (
 	LevelPanic
 )
 
-func (lv *Level) String() string {
-	return LevelStrings[*lv]
+// String returns the lower-case name of the level, like "debug".
+func (lv Level) String() string {
+	if s, ok := LevelStrings[lv]; ok {
+		return s
+	}
+	return fmt.Sprintf("Level(%d)", int(lv))
+}
+
+// ParseLevel parses a level name case-insensitively, like "debug" or "WARN".
+// An error is returned for unknown names.
+func ParseLevel(s string) (Level, error) {
+	if lv, ok := LevelNames[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return lv, nil
+	}
+	return LevelOff, fmt.Errorf("%w: %q, allowed: %s", ec.ErrUnknownLevel, s, strings.Join(levelNames(), ", "))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (lv Level) MarshalText() ([]byte, error) {
+	s, ok := LevelStrings[lv]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ec.ErrUnknownLevel, int(lv))
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (lv *Level) UnmarshalText(text []byte) error {
+	v, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lv = v
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (lv Level) MarshalYAML() (any, error) {
+	text, err := lv.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (lv *Level) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	if err := lv.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (lv Level) MarshalJSON() ([]byte, error) {
+	text, err := lv.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (lv *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return lv.UnmarshalText([]byte(s))
 }
 
 var (
 	// LevelStrings is the map from log level to its string representation.
 	LevelStrings = map[Level]string{
+		LevelOff:   "off",
 		LevelTrace: "trace",
 		LevelDebug: "debug",
 		LevelInfo:  "info",
@@ -33,6 +114,7 @@ var (
 	}
 	// LevelNames is the map from string to log level.
 	LevelNames = map[string]Level{
+		"off":   LevelOff,
 		"trace": LevelTrace,
 		"debug": LevelDebug,
 		"info":  LevelInfo,
@@ -43,6 +125,21 @@ var (
 	}
 )
 
+// AllOutputs addresses all outputs of a Logger in SetLevel and GetLevel.
+const AllOutputs = "*"
+
+// OutputLevel describes the current level of an output.
+type OutputLevel struct {
+	// Index is the position of the output in the config.
+	Index int
+	// Name is the configured name of the output, may be empty.
+	Name string
+	// Writer is the writer type of the output, like "console" or "file".
+	Writer string
+	// Level is the current log level of the output.
+	Level Level
+}
+
 // LoggerOptions is the log options.
 type LoggerOptions struct {
 	LogLevel Level
@@ -53,10 +150,18 @@ type LoggerOptions struct {
 // LoggerOption modifies the LoggerOptions.
 type LoggerOption func(options *LoggerOptions)
 
-// Field is the user defined log field.
+// Field is the user defined log field. A Field literal with only Key and Value
+// set is encoded by reflection, use the typed constructors such as String or
+// Int64 to avoid it.
 type Field struct {
 	Key   string
 	Value any
+
+	// Type, Integer and String hold the typed value set by the field
+	// constructors, so that primitive values are never boxed.
+	Type    FieldType
+	Integer int64
+	String  string
 }
 
 // Logger provides an abstract definition for logging functionality.
@@ -111,21 +216,72 @@ type Logger interface {
 	// Panicln logs a message at [LevelPanic]. Spaces are always added between arguments.
 	Panicln(args ...any)
 
+	// Log logs msg with fields at level. The message is not formatted and the
+	// fields are passed to the backend as they are.
+	Log(level Level, msg string, fields ...Field)
+	// TraceFields logs msg with fields at [LevelTrace].
+	TraceFields(msg string, fields ...Field)
+	// DebugFields logs msg with fields at [LevelDebug].
+	DebugFields(msg string, fields ...Field)
+	// InfoFields logs msg with fields at [LevelInfo].
+	InfoFields(msg string, fields ...Field)
+	// WarnFields logs msg with fields at [LevelWarn].
+	WarnFields(msg string, fields ...Field)
+	// ErrorFields logs msg with fields at [LevelError].
+	ErrorFields(msg string, fields ...Field)
+	// FatalFields logs msg with fields at [LevelFatal].
+	FatalFields(msg string, fields ...Field)
+	// PanicFields logs msg with fields at [LevelPanic].
+	PanicFields(msg string, fields ...Field)
+
 	// Sync calls the underlying Core's Sync method, flushing any buffer log entries.
 	// Applications should take care to call Sync before exiting.
 	Sync() error
 
-	// SetLevel sets the output log level.
-	SetLevel(output string, level Level)
+	// Close flushes the logger and closes its writers, releasing files and
+	// background goroutines. The logger should not be used after closing.
+	Close() error
 
-	// GetLevel gets the output log level.
-	GetLevel(output string) Level
+	// SetLevel sets the output log level. Outputs are addressed by name, by
+	// writer type, by index, or AllOutputs for all of them. An error is returned
+	// if `output` matches nothing.
+	SetLevel(output string, level Level) error
+
+	// GetLevel gets the output log level. Outputs are addressed in the same way
+	// as SetLevel, the lowest level is returned if more than one is matched.
+	GetLevel(output string) (Level, error)
+
+	// OutputLevels lists every output with its current level.
+	OutputLevels() []OutputLevel
 
 	// With returns a new logger with key/value paris.
 	With(args ...any) Logger
 
 	// WithFields returns a new logger with `fields` set.
 	WithFields(fields ...Field) Logger
+
+	// Named returns a new logger with `name` appended to its name. Nested names
+	// are separated by periods, like "matchmaking.queue".
+	Named(name string) Logger
+}
+
+// LevelEnabler is implemented by loggers which can report whether a level is
+// printed by any of their outputs, so that callers may skip building costly logs.
+type LevelEnabler interface {
+	Enabled(level Level) bool
+}
+
+// RecordLogger is implemented by loggers which can write a log with the time and
+// caller given by the caller, like the ones of log/slog records. The caller is
+// left out if pc is zero, and the current time is used if t is zero.
+type RecordLogger interface {
+	LogRecord(t time.Time, pc uintptr, level Level, msg string, fields ...Field)
+}
+
+// ContextCloser is implemented by loggers which can close within a context, so
+// that Shutdown returns when its ctx is done.
+type ContextCloser interface {
+	CloseContext(ctx context.Context) error
 }
 
 type OptionLogger interface {
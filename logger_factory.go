@@ -1,6 +1,7 @@
 package zeus_log
 
 import (
+	"errors"
 	"github.com/oyogames2023/zeus-log/plugin"
 	"sync"
 )
@@ -19,24 +20,49 @@ var (
 )
 
 // Register registers Logger. It supports multiple Logger implementation.
+// Except for the default one, the logger is named after `name`, so all lines
+// it prints are attributed to the registered name. It panics if a logger is
+// already registered by the name, use RegisterE to get the error.
 func Register(name string, logger Logger) {
+	if err := RegisterE(name, logger); err != nil {
+		panic(err.Error())
+	}
+}
+
+// RegisterE is Register which returns an error instead of panic if logger is
+// nil, or a logger other than the default one is already registered by the name.
+func RegisterE(name string, logger Logger) error {
 	mu.Lock()
 	defer mu.Unlock()
 	if logger == nil {
-		panic("log: Register logger is nil")
+		return errors.New("log: Register logger is nil")
 	}
 	if _, dup := loggers[name]; dup && name != defaultLoggerName {
-		panic("log: Register called twice for logger name " + name)
+		return errors.New("log: Register called twice for logger name " + name)
+	}
+	if name != defaultLoggerName {
+		logger = logger.Named(name)
 	}
 	loggers[name] = logger
 	if name == defaultLoggerName {
 		DefaultLogger = logger
 	}
+	return nil
+}
+
+// isRegistered reports whether a logger other than the default one is
+// registered by the name.
+func isRegistered(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := loggers[name]
+	return ok && name != defaultLoggerName
 }
 
 // GetDefaultLogger gets the default Logger.
 // To configure it, set key in configuration file to default.
-// The console output is the default value.
+// The console output is the default value, which is set up by importing a log
+// backend like log/zap.
 func GetDefaultLogger() Logger {
 	mu.RLock()
 	l := DefaultLogger
@@ -51,11 +77,15 @@ func SetLogger(logger Logger) {
 	mu.Unlock()
 }
 
-// Get returns the Logger implementation by log name.
+// Get returns the Logger implementation by log name, or the default Logger if
+// there is no Logger registered by the name.
 // log.Debug use DefaultLogger to print logs. You may also use log.Get("name").Debug.
 func Get(name string) Logger {
 	mu.RLock()
-	l := loggers[name]
+	l, ok := loggers[name]
+	if !ok {
+		l = DefaultLogger
+	}
 	mu.RUnlock()
 	return l
 }
@@ -0,0 +1,144 @@
+package zeus_log
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestLevelString(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{level: LevelOff, want: "off"},
+		{level: LevelTrace, want: "trace"},
+		{level: LevelDebug, want: "debug"},
+		{level: LevelPanic, want: "panic"},
+		{level: Level(100), want: "Level(100)"},
+	}
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.want {
+			t.Errorf("Level(%d).String() = %q, want %q", int(tt.level), got, tt.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{in: "off", want: LevelOff},
+		{in: "OFF", want: LevelOff},
+		{in: " Warn ", want: LevelWarn},
+		{in: "trace", want: LevelTrace},
+		{in: "warning", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ec.ErrUnknownLevel) {
+				t.Errorf("ParseLevel(%q) err = %v, want %v", tt.in, err, ec.ErrUnknownLevel)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestLevelMarshaling(t *testing.T) {
+	type config struct {
+		Level Level `yaml:"level" json:"level"`
+	}
+
+	data, err := json.Marshal(config{Level: LevelOff})
+	if err != nil || string(data) != `{"level":"off"}` {
+		t.Errorf("json.Marshal = %s, %v", data, err)
+	}
+	var jc config
+	if err := json.Unmarshal([]byte(`{"level":"ERROR"}`), &jc); err != nil || jc.Level != LevelError {
+		t.Errorf("json.Unmarshal = %v, %v, want %v", jc.Level, err, LevelError)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &jc); !errors.Is(err, ec.ErrUnknownLevel) {
+		t.Errorf("json.Unmarshal unknown level err = %v", err)
+	}
+
+	data, err = yaml.Marshal(config{Level: LevelInfo})
+	if err != nil || string(data) != "level: info\n" {
+		t.Errorf("yaml.Marshal = %q, %v", data, err)
+	}
+	var yc config
+	if err := yaml.Unmarshal([]byte("level: Off"), &yc); err != nil || yc.Level != LevelOff {
+		t.Errorf("yaml.Unmarshal = %v, %v, want %v", yc.Level, err, LevelOff)
+	}
+	if err := yaml.Unmarshal([]byte("\nlevel: loud"), &yc); !errors.Is(err, ec.ErrUnknownLevel) {
+		t.Errorf("yaml.Unmarshal unknown level err = %v", err)
+	}
+
+	if _, err := Level(100).MarshalText(); !errors.Is(err, ec.ErrUnknownLevel) {
+		t.Errorf("MarshalText unknown level err = %v", err)
+	}
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	type config struct {
+		Level Level `yaml:"level" json:"level"`
+	}
+	for lv := LevelOff; lv <= LevelPanic; lv++ {
+		text, err := lv.MarshalText()
+		if err != nil || string(text) != lv.String() {
+			t.Errorf("%v.MarshalText = %q, %v", lv, text, err)
+		}
+		var fromText Level
+		if err := fromText.UnmarshalText([]byte(strings.ToUpper(string(text)))); err != nil || fromText != lv {
+			t.Errorf("UnmarshalText(%q) = %v, %v, want %v", strings.ToUpper(string(text)), fromText, err, lv)
+		}
+
+		data, err := json.Marshal(config{Level: lv})
+		var jc config
+		if err == nil {
+			err = json.Unmarshal(data, &jc)
+		}
+		if err != nil || jc.Level != lv {
+			t.Errorf("json round trip of %v = %v, %v (%s)", lv, jc.Level, err, data)
+		}
+
+		data, err = yaml.Marshal(config{Level: lv})
+		var yc config
+		if err == nil {
+			err = yaml.Unmarshal(data, &yc)
+		}
+		if err != nil || yc.Level != lv {
+			t.Errorf("yaml round trip of %v = %v, %v (%s)", lv, yc.Level, err, data)
+		}
+	}
+}
+
+func TestLevelUnmarshalInvalid(t *testing.T) {
+	for _, in := range []string{"verbose", "warning", "", "1"} {
+		lv := LevelInfo
+		err := lv.UnmarshalText([]byte(in))
+		if !errors.Is(err, ec.ErrUnknownLevel) {
+			t.Errorf("UnmarshalText(%q) err = %v, want %v", in, err, ec.ErrUnknownLevel)
+		}
+		if lv != LevelInfo {
+			t.Errorf("UnmarshalText(%q) changed the level to %v", in, lv)
+		}
+	}
+	var lv Level
+	if err := json.Unmarshal([]byte(`1`), &lv); err == nil {
+		t.Error("json.Unmarshal of a number succeeded, want error")
+	}
+	if _, err := yaml.Marshal(map[string]Level{"level": Level(100)}); err == nil {
+		t.Error("yaml.Marshal of an unknown level succeeded, want error")
+	}
+}
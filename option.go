@@ -5,6 +5,9 @@ type Option func(*Options)
 
 type Options struct {
 	Skip int
+	// StacktraceLevel is the level from which stack traces are attached to
+	// logs, LevelOff leaves it to the outputs.
+	StacktraceLevel Level
 }
 
 // WithAdditionalCallerSkip adds additional caller skip.
@@ -13,3 +16,12 @@ func WithAdditionalCallerSkip(skip int) Option {
 		o.Skip = skip
 	}
 }
+
+// WithStacktraceLevel attaches stack traces to logs at level and above, in
+// addition to the stacktrace_level of outputs. Loggers which do not capture
+// stack traces ignore it.
+func WithStacktraceLevel(level Level) Option {
+	return func(o *Options) {
+		o.StacktraceLevel = level
+	}
+}
@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	yaml "gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+// YAMLNodeDecoder is the Decoder of plugin configuration in yaml. Decoding
+// is strict: keys in the node which have no field in the target struct fail
+// with an error, so typos in configuration are reported.
+type YAMLNodeDecoder struct {
+	Node *yaml.Node
+}
+
+// NewYAMLNodeDecoder creates a YAMLNodeDecoder of node.
+func NewYAMLNodeDecoder(node *yaml.Node) *YAMLNodeDecoder {
+	return &YAMLNodeDecoder{Node: node}
+}
+
+// Decode decodes the node into cfg, which should be a pointer. cfg is left
+// untouched if the node is empty. Errors are reported by the lines of the
+// node in the document it's parsed from, like "line 9: field levle not found
+// in type zeus_log.OutputConfig".
+func (d *YAMLNodeDecoder) Decode(cfg any) error {
+	if d.Node == nil || d.Node.Kind == 0 {
+		return nil
+	}
+	// yaml.Node.Decode does not check unknown fields, they are checked by
+	// walking the node, so the lines of the node are kept.
+	var unknown []string
+	checkKnownFields(d.Node, reflect.TypeOf(cfg), &unknown)
+	err := d.Node.Decode(cfg)
+	if len(unknown) == 0 {
+		return err
+	}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		unknown = append(unknown, typeErr.Errors...)
+	} else if err != nil {
+		return err
+	}
+	return &yaml.TypeError{Errors: unknown}
+}
+
+var (
+	yamlNodeType        = reflect.TypeOf(yaml.Node{})
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// checkKnownFields appends the keys of mappings in n which have no field in
+// the structs of type t to unknown, in the form of yaml errors. Types which
+// decode themselves are not checked.
+func checkKnownFields(n *yaml.Node, t reflect.Type, unknown *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.DocumentNode {
+		for _, c := range n.Content {
+			checkKnownFields(c, t, unknown)
+		}
+		return
+	}
+	if t == yamlNodeType || reflect.PointerTo(t).Implements(yamlUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields, anyKey := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				checkMerge(value, t, unknown)
+				continue
+			}
+			if ft, ok := fields[key.Value]; ok {
+				checkKnownFields(value, ft, unknown)
+			} else if !anyKey {
+				*unknown = append(*unknown, fmt.Sprintf("line %d: field %s not found in type %s",
+					key.Line, key.Value, t))
+			}
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			checkKnownFields(n.Content[i+1], t.Elem(), unknown)
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && n.Kind == yaml.SequenceNode:
+		for _, c := range n.Content {
+			checkKnownFields(c, t.Elem(), unknown)
+		}
+	}
+}
+
+// checkMerge checks the mappings merged into a struct by the "<<" key.
+func checkMerge(n *yaml.Node, t reflect.Type, unknown *[]string) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.SequenceNode {
+		for _, c := range n.Content {
+			checkKnownFields(c, t, unknown)
+		}
+		return
+	}
+	checkKnownFields(n, t, unknown)
+}
+
+// yamlFields returns the types of the fields of struct t by their yaml keys,
+// with the fields of inlined structs. anyKey is true if t inlines a map, which
+// takes any key.
+func yamlFields(t reflect.Type) (fields map[string]reflect.Type, anyKey bool) {
+	fields = make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		if strings.Contains(","+flags+",", ",inline,") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Map {
+				anyKey = true
+				continue
+			}
+			inlined, inlinedAny := yamlFields(ft)
+			for k, v := range inlined {
+				fields[k] = v
+			}
+			anyKey = anyKey || inlinedAny
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields, anyKey
+}
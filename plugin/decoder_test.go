@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+type testWriterConfig struct {
+	FileName string `yaml:"file_name"`
+	MaxSize  int    `yaml:"max_size"`
+}
+
+type testOutputConfig struct {
+	Writer       string           `yaml:"writer"`
+	WriterConfig testWriterConfig `yaml:"writer_config"`
+	Level        string           `yaml:"level"`
+	Remote       yaml.Node        `yaml:"remote_config"`
+}
+
+type testInline struct {
+	testWriterConfig `yaml:",inline"`
+	Extra            map[string]any `yaml:",inline"`
+}
+
+// parseNode parses the value of the key "log" in doc.
+func parseNode(t *testing.T, doc string) *yaml.Node {
+	t.Helper()
+	var v struct {
+		Log yaml.Node `yaml:"log"`
+	}
+	if err := yaml.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return &v.Log
+}
+
+func TestYAMLNodeDecoderUnknownFieldLines(t *testing.T) {
+	node := parseNode(t, `
+server:
+  port: 80
+
+log:
+  - writer: console
+    level: info
+  - writer: file
+    levle: debug
+    remote_config:
+      anything: goes
+    writer_config:
+      file_name: a.log
+
+      max_sizee: 10
+`)
+	var cfg []testOutputConfig
+	err := NewYAMLNodeDecoder(node).Decode(&cfg)
+	if err == nil {
+		t.Fatal("Decode succeeded, want unknown field errors")
+	}
+	for _, want := range []string{
+		"line 9: field levle not found in type plugin.testOutputConfig",
+		"line 15: field max_sizee not found in type plugin.testWriterConfig",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "anything") {
+		t.Errorf("err = %v, keys of yaml.Node fields should not be checked", err)
+	}
+}
+
+func TestYAMLNodeDecoderTypeErrorLines(t *testing.T) {
+	node := parseNode(t, `
+log:
+  - writer: file
+    writer_config:
+      max_size: big
+    levle: debug
+`)
+	var cfg []testOutputConfig
+	err := NewYAMLNodeDecoder(node).Decode(&cfg)
+	if err == nil {
+		t.Fatal("Decode succeeded, want errors")
+	}
+	for _, want := range []string{"line 5: cannot unmarshal", "line 6: field levle not found"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestYAMLNodeDecoderInlineAndMerge(t *testing.T) {
+	node := parseNode(t, `
+base: &base
+  file_name: a.log
+log:
+  <<: *base
+  max_size: 10
+  other: 1
+`)
+	var cfg testInline
+	if err := NewYAMLNodeDecoder(node).Decode(&cfg); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cfg.FileName != "a.log" || cfg.MaxSize != 10 || cfg.Extra["other"] != 1 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	node = parseNode(t, `
+base: &base
+  file_nam: a.log
+log:
+  <<: *base
+`)
+	var wc testWriterConfig
+	if err := NewYAMLNodeDecoder(node).Decode(&wc); err == nil || !strings.Contains(err.Error(), "line 3: field file_nam") {
+		t.Errorf("err = %v, want unknown merged field", err)
+	}
+}
+
+func TestYAMLNodeDecoderEmpty(t *testing.T) {
+	cfg := testWriterConfig{FileName: "kept"}
+	if err := NewYAMLNodeDecoder(&yaml.Node{}).Decode(&cfg); err != nil || cfg.FileName != "kept" {
+		t.Errorf("Decode empty node = %+v, %v", cfg, err)
+	}
+}
@@ -1,6 +1,14 @@
 package plugin
 
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
 var (
+	lock    sync.RWMutex
 	plugins = make(map[string]map[string]Factory)
 )
 
@@ -17,22 +25,83 @@ type Decoder interface {
 	Decode(cfg any) error
 }
 
+// Flusher is optionally implemented by factories whose plugins buffer data.
+type Flusher interface {
+	// Flush flushes the data buffered by the plugins set up by the factory.
+	Flush() error
+}
+
+// Closer is optionally implemented by factories whose plugins hold resources.
+type Closer interface {
+	// Close releases the resources of the plugins set up by the factory. It
+	// should return when ctx is done, even if not everything is released.
+	Close(ctx context.Context) error
+}
+
 // Register registers a plugin factory. Name of the plugin should be specified.
 // It is supported to register instances which are the same implementation of
-// plugin Factory, but use different configuration.
+// plugin Factory, but use different configuration. It panics if a factory is
+// already registered by the same type and name, use RegisterE to get the error.
 func Register(name string, factory Factory) {
+	if err := RegisterE(name, factory); err != nil {
+		panic(err.Error())
+	}
+}
+
+// RegisterE is Register which returns an error instead of panic if a factory is
+// already registered by the same type and name.
+func RegisterE(name string, factory Factory) error {
+	lock.Lock()
+	defer lock.Unlock()
 	factories, ok := plugins[factory.Type()]
 	if !ok {
 		factories = make(map[string]Factory)
 		plugins[factory.Type()] = factories
 	}
+	if _, dup := factories[name]; dup {
+		return fmt.Errorf("plugin: %s plugin %s registered twice", factory.Type(), name)
+	}
 	factories[name] = factory
+	return nil
+}
+
+// Unregister removes the plugin Factory by its type and name, and reports
+// whether it was registered.
+func Unregister(factoryType string, name string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+	factories, ok := plugins[factoryType]
+	if !ok {
+		return false
+	}
+	if _, ok := factories[name]; !ok {
+		return false
+	}
+	delete(factories, name)
+	if len(factories) == 0 {
+		delete(plugins, factoryType)
+	}
+	return true
 }
 
 // Get returns a plugin Factory by its type and name.
 func Get(factoryType string, name string) Factory {
+	lock.RLock()
+	defer lock.RUnlock()
 	if factories, ok := plugins[factoryType]; ok {
 		return factories[name]
 	}
 	return nil
 }
+
+// List returns the sorted names of plugins registered by the type.
+func List(factoryType string) []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(plugins[factoryType]))
+	for name := range plugins[factoryType] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testFactory struct{}
+
+func (testFactory) Type() string {
+	return "plugin-test"
+}
+
+func (testFactory) Setup(string, Decoder) error {
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() {
+		Unregister("plugin-test", "a")
+		Unregister("plugin-test", "b")
+	})
+	Register("b", testFactory{})
+	if err := RegisterE("a", testFactory{}); err != nil {
+		t.Fatalf("RegisterE: %v", err)
+	}
+	if err := RegisterE("a", testFactory{}); err == nil {
+		t.Error("RegisterE twice succeeded, want error")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Register twice did not panic")
+			}
+		}()
+		Register("b", testFactory{})
+	}()
+	if got := List("plugin-test"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("List = %v, want [a b]", got)
+	}
+	if Get("plugin-test", "a") == nil {
+		t.Error("Get registered plugin returned nil")
+	}
+	if !Unregister("plugin-test", "a") || Unregister("plugin-test", "a") {
+		t.Error("Unregister should report whether the plugin was registered")
+	}
+	if Get("plugin-test", "a") != nil {
+		t.Error("Get unregistered plugin returned non-nil")
+	}
+}
@@ -0,0 +1,242 @@
+package zeus_log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/oyogames2023/zeus-log/plugin"
+	yaml "gopkg.in/yaml.v3"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Reloader is implemented by loggers whose config can be replaced at runtime.
+type Reloader interface {
+	// Reload replaces the outputs of the logger with the ones built from cfg,
+	// and reports what changed. The old outputs are closed within ctx once the
+	// logs being written to them are done, or in the background if ctx is done
+	// first. The old config is kept if an error is returned.
+	Reload(ctx context.Context, cfg Config) (ConfigDiff, error)
+}
+
+// ConfigDiff describes what changed between two configs of a logger. Outputs
+// are identified by their names, by their file paths for file outputs, or by
+// their writers and positions among the outputs of the same writer.
+type ConfigDiff struct {
+	// Added lists the outputs which are only in the new config.
+	Added []string
+	// Removed lists the outputs which are only in the old config.
+	Removed []string
+	// Changed lists the changed fields of outputs in both configs, like
+	// `console#0: level: "debug" -> "info"`.
+	Changed []string
+}
+
+// Empty reports whether nothing changed.
+func (d ConfigDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String returns a summary of the changes.
+func (d ConfigDiff) String() string {
+	if d.Empty() {
+		return "no change"
+	}
+	var parts []string
+	for _, a := range d.Added {
+		parts = append(parts, "added "+a)
+	}
+	for _, r := range d.Removed {
+		parts = append(parts, "removed "+r)
+	}
+	parts = append(parts, d.Changed...)
+	return strings.Join(parts, "; ")
+}
+
+// DiffConfig compares two configs of a logger.
+func DiffConfig(old, new Config) ConfigDiff {
+	var diff ConfigDiff
+	oldKeys, newKeys := outputKeys(old), outputKeys(new)
+	oldIndex := make(map[string]int, len(old))
+	for i, k := range oldKeys {
+		oldIndex[k] = i
+	}
+	seen := make(map[string]bool, len(new))
+	for i, k := range newKeys {
+		seen[k] = true
+		j, ok := oldIndex[k]
+		if !ok {
+			diff.Added = append(diff.Added, k)
+			continue
+		}
+		diffValue(&diff, k+": ", "", reflect.ValueOf(old[j]), reflect.ValueOf(new[i]))
+	}
+	for _, k := range oldKeys {
+		if !seen[k] {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+	return diff
+}
+
+// outputKeys returns the keys identifying outputs across configs.
+func outputKeys(cfg Config) []string {
+	keys := make([]string, len(cfg))
+	nth := make(map[string]int)
+	for i, c := range cfg {
+		switch {
+		case c.Name != "":
+			keys[i] = c.Name
+		case c.Writer == OutputFile && c.WriterConfig.FileName != "":
+			keys[i] = c.Writer + ":" + path.Join(c.WriterConfig.LogPath, c.WriterConfig.FileName)
+		default:
+			keys[i] = fmt.Sprintf("%s#%d", c.Writer, nth[c.Writer])
+			nth[c.Writer]++
+		}
+	}
+	return keys
+}
+
+// diffValue appends the differences between the fields of two config values
+// to diff, with field paths made of yaml tags.
+func diffValue(diff *ConfigDiff, prefix, field string, old, new reflect.Value) {
+	if old.Type() == reflect.TypeOf(yaml.Node{}) {
+		oldNode, newNode := old.Interface().(yaml.Node), new.Interface().(yaml.Node)
+		oldData, _ := yaml.Marshal(&oldNode)
+		newData, _ := yaml.Marshal(&newNode)
+		if !bytes.Equal(oldData, newData) {
+			diff.Changed = append(diff.Changed, prefix+field+" changed")
+		}
+		return
+	}
+	if (old.Kind() == reflect.Map || old.Kind() == reflect.Slice) && old.Len() == 0 && new.Len() == 0 {
+		// Nil and empty maps or slices are the same in configs.
+		return
+	}
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			tag := strings.Split(old.Type().Field(i).Tag.Get("yaml"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			if field != "" {
+				tag = field + "." + tag
+			}
+			diffValue(diff, prefix, tag, old.Field(i), new.Field(i))
+		}
+		return
+	}
+	if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+		diff.Changed = append(diff.Changed,
+			fmt.Sprintf("%s%s: %#v -> %#v", prefix, field, old.Interface(), new.Interface()))
+	}
+}
+
+// ReloadFromFile reads the log config document at path and reloads the loggers
+// in it, see ReloadFromBytes.
+func ReloadFromFile(ctx context.Context, path string) (map[string]ConfigDiff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("log: read config file: %w", err)
+	}
+	return ReloadFromBytes(ctx, data)
+}
+
+// ReloadFromBytes parses a log config document in the format of SetupFromBytes,
+// and applies it to the running loggers. Registered loggers are reloaded in
+// place, so loggers already handed out use the new outputs; loggers new to the
+// document are set up and registered. Loggers missing from the document are
+// left untouched. Overrides from environment variables are applied to the
+// config first, see ApplyEnvOverrides. The changes of each logger are returned
+// by its name, and a logger whose reload fails keeps its old config. Replaced
+// outputs are closed within ctx, see Reloader.
+func ReloadFromBytes(ctx context.Context, data []byte) (map[string]ConfigDiff, error) {
+	fc, err := parseFileConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make(map[string]ConfigDiff)
+	var errs error
+	for i := 0; i+1 < len(fc.Log.Content); i += 2 {
+		name, node := fc.Log.Content[i].Value, fc.Log.Content[i+1]
+		diff, err := reloadLogger(ctx, name, node)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("log: reload logger %q: %w", name, err))
+			continue
+		}
+		diffs[name] = diff
+	}
+	return diffs, errs
+}
+
+func reloadLogger(ctx context.Context, name string, node *yaml.Node) (ConfigDiff, error) {
+	mu.RLock()
+	logger, ok := loggers[name]
+	mu.RUnlock()
+	if !ok {
+		node, overrides, envErr := envOverriddenNode(name, node)
+		var cfg Config
+		if err := plugin.NewYAMLNodeDecoder(node).Decode(&cfg); err != nil {
+			return ConfigDiff{}, err
+		}
+		if err := setupLogger(name, plugin.NewYAMLNodeDecoder(node)); err != nil {
+			return ConfigDiff{}, multierror.Append(envErr, err)
+		}
+		RecordEnvOverrides(name, overrides)
+		return DiffConfig(nil, cfg), envErr
+	}
+	r, ok := logger.(Reloader)
+	if !ok {
+		return ConfigDiff{}, fmt.Errorf("logger %T does not support reload", logger)
+	}
+	var cfg Config
+	if err := plugin.NewYAMLNodeDecoder(node).Decode(&cfg); err != nil {
+		return ConfigDiff{}, err
+	}
+	cfg, overrides, envErr := ApplyEnvOverrides(name, cfg)
+	diff, err := r.Reload(ctx, cfg)
+	if err != nil {
+		return ConfigDiff{}, multierror.Append(envErr, err)
+	}
+	RecordEnvOverrides(name, overrides)
+	return diff, envErr
+}
+
+// WatchConfigFile polls the log config document at path every interval, and
+// reloads the loggers by ReloadFromFile when the file is modified. The result
+// of each reload is passed to onReload, which may be nil. It blocks until ctx
+// is done, which also bounds the time each reload waits to close replaced
+// outputs.
+func WatchConfigFile(ctx context.Context, path string, interval time.Duration,
+	onReload func(diffs map[string]ConfigDiff, err error)) error {
+	if interval <= 0 {
+		return errors.New("log: watch config file: interval should be positive")
+	}
+	last, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("log: watch config file: %w", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			st, err := os.Stat(path)
+			if err != nil || (st.ModTime().Equal(last.ModTime()) && st.Size() == last.Size()) {
+				continue
+			}
+			last = st
+			diffs, err := ReloadFromFile(ctx, path)
+			if onReload != nil {
+				onReload(diffs, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package zeus_log
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffConfigNilAndEmpty(t *testing.T) {
+	old := Config{{Writer: OutputConsole, Sampling: SamplingConfig{Initial: 1}}}
+	new := Config{{Writer: OutputConsole, Sampling: SamplingConfig{Initial: 1, Levels: map[string]SamplingPolicy{}}}}
+	if diff := DiffConfig(old, new); !diff.Empty() {
+		t.Errorf("diff = %v, want no change", diff)
+	}
+	if diff := DiffConfig(old, old); !diff.Empty() {
+		t.Errorf("diff of the same config = %v, want no change", diff)
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	old := Config{
+		{Name: "main", Writer: OutputConsole, Level: "info"},
+		{Writer: OutputFile, WriterConfig: WriterConfig{LogPath: "/var/log", FileName: "a.log"}},
+		{Writer: OutputConsole},
+	}
+	new := Config{
+		{Name: "main", Writer: OutputConsole, Level: "warn",
+			Sampling: SamplingConfig{Levels: map[string]SamplingPolicy{"debug": {Initial: 1}}}},
+		{Writer: OutputFile, WriterConfig: WriterConfig{LogPath: "/var/log", FileName: "b.log"}},
+	}
+	want := ConfigDiff{
+		Added:   []string{"file:/var/log/b.log"},
+		Removed: []string{"file:/var/log/a.log", "console#0"},
+		Changed: []string{
+			`main: level: "info" -> "warn"`,
+			`main: sampling.levels: map[string]zeus_log.SamplingPolicy(nil) -> ` +
+				`map[string]zeus_log.SamplingPolicy{"debug":zeus_log.SamplingPolicy{Initial:1, Thereafter:0}}`,
+		},
+	}
+	if got := DiffConfig(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("diff = %#v, want %#v", got, want)
+	}
+}
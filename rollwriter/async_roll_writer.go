@@ -2,12 +2,15 @@ package rollwriter
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
 )
 
 const (
@@ -27,6 +30,14 @@ type AsyncRollWriter struct {
 	syncErr  chan error
 	close    chan struct{}
 	closeErr chan error
+	// done is closed when the goroutine writing logs exits.
+	done chan struct{}
+
+	// mu guards closed, so that no log is queued after closing. Write holds
+	// the read lock until its log is queued.
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
 }
 
 // NewAsyncRollWriter creates a new AsyncRollWriter.
@@ -49,7 +60,8 @@ func NewAsyncRollWriter(logger io.WriteCloser, opt ...AsyncOption) *AsyncRollWri
 		sync:     make(chan struct{}),
 		syncErr:  make(chan error),
 		close:    make(chan struct{}),
-		closeErr: make(chan error),
+		closeErr: make(chan error, 1),
+		done:     make(chan struct{}),
 	}
 
 	// Start a new goroutine to write batch logs.
@@ -58,7 +70,13 @@ func NewAsyncRollWriter(logger io.WriteCloser, opt ...AsyncOption) *AsyncRollWri
 }
 
 // Write writes logs. It implements io.Writer.
+// ErrWriterClosed is returned after the writer is closed.
 func (w *AsyncRollWriter) Write(data []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, ec.ErrWriterClosed
+	}
 	log := make([]byte, len(data))
 	copy(log, data)
 	if w.opts.DropLog {
@@ -75,15 +93,46 @@ func (w *AsyncRollWriter) Write(data []byte) (int, error) {
 
 // Sync syncs logs. It implements zapcore.WriteSyncer.
 func (w *AsyncRollWriter) Sync() error {
-	w.sync <- struct{}{}
-	return <-w.syncErr
+	select {
+	case w.sync <- struct{}{}:
+		return <-w.syncErr
+	case <-w.done:
+		return ec.ErrWriterClosed
+	}
 }
 
-// Close closes current log file. It implements io.Closer.
+// Close writes all queued logs, closes current log file and stops the
+// goroutine writing logs. It implements io.Closer.
 func (w *AsyncRollWriter) Close() error {
-	err := w.Sync()
-	close(w.close)
-	return multierror.Append(err, <-w.closeErr).ErrorOrNil()
+	return w.CloseContext(context.Background())
+}
+
+// CloseContext is Close which returns when ctx is done, with an error
+// reporting how many logs are not written yet. They are still written in
+// background. Closing a closed writer does nothing.
+func (w *AsyncRollWriter) CloseContext(ctx context.Context) error {
+	w.closeOnce.Do(func() {
+		// Writes blocked on a full queue hold the read lock until the queued
+		// logs are written, which may take longer than ctx, so the lock is
+		// not waited for here.
+		go func() {
+			w.mu.Lock()
+			w.closed = true
+			w.mu.Unlock()
+			close(w.close)
+		}()
+	})
+	select {
+	case <-w.done:
+		select {
+		case err := <-w.closeErr:
+			return err
+		default:
+			return nil
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("async roll writer: %d logs not flushed: %w", len(w.logQueue), ctx.Err())
+	}
 }
 
 // batchWriteLog asynchronously writes logs in batches.
@@ -122,7 +171,15 @@ func (w *AsyncRollWriter) batchWriteLog() {
 			}
 			w.syncErr <- err
 		case <-w.close:
-			w.closeErr <- w.logger.Close()
+			var err error
+			for n := len(w.logQueue); n > 0; n-- {
+				buffer.Write(<-w.logQueue)
+			}
+			if buffer.Len() > 0 {
+				_, err = w.logger.Write(buffer.Bytes())
+			}
+			w.closeErr <- multierror.Append(err, w.logger.Close()).ErrorOrNil()
+			close(w.done)
 			return
 		}
 	}
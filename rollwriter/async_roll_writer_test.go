@@ -0,0 +1,170 @@
+package rollwriter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+)
+
+// blockingWriter is an io.WriteCloser whose writes block while it's blocked.
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	closed  bool
+	unblock chan struct{}
+	writing chan struct{}
+}
+
+func newBlockingWriter() *blockingWriter {
+	w := &blockingWriter{unblock: make(chan struct{}), writing: make(chan struct{}, 100)}
+	close(w.unblock)
+	return w
+}
+
+func (w *blockingWriter) block() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.unblock = make(chan struct{})
+}
+
+func (w *blockingWriter) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	close(w.unblock)
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.writing <- struct{}{}
+	w.mu.Lock()
+	unblock := w.unblock
+	w.mu.Unlock()
+	<-unblock
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *blockingWriter) state() (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String(), w.closed
+}
+
+func TestAsyncRollWriterCloseDrainsQueue(t *testing.T) {
+	bw := newBlockingWriter()
+	// Logs stay queued until closing.
+	w := NewAsyncRollWriter(bw, WithWriteLogSize(1<<20), WithWriteLogInterval(int(time.Hour/time.Millisecond)))
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write([]byte("line\n")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		want.WriteString("line\n")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	got, closed := bw.state()
+	if got != want.String() || !closed {
+		t.Errorf("written %d bytes, closed = %v, want %d bytes and closed", len(got), closed, want.Len())
+	}
+}
+
+func TestAsyncRollWriterWriteAfterClose(t *testing.T) {
+	w := NewAsyncRollWriter(newBlockingWriter())
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte("late\n")); !errors.Is(err, ec.ErrWriterClosed) {
+		t.Errorf("Write after Close err = %v, want %v", err, ec.ErrWriterClosed)
+	}
+	if err := w.Sync(); !errors.Is(err, ec.ErrWriterClosed) {
+		t.Errorf("Sync after Close err = %v, want %v", err, ec.ErrWriterClosed)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close err = %v, want nil", err)
+	}
+}
+
+func TestAsyncRollWriterCloseContextDeadline(t *testing.T) {
+	bw := newBlockingWriter()
+	bw.block()
+	w := NewAsyncRollWriter(bw, WithLogQueueSize(1), WithWriteLogSize(1))
+	// The first log is being written, the second is queued, and the third
+	// blocks on the full queue.
+	if _, err := w.Write([]byte("1\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	<-bw.writing
+	if _, err := w.Write([]byte("2\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	written := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("3\n"))
+		written <- err
+	}()
+	// Usually lets the third write block before closing, either way every
+	// log accepted by Write is written.
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := w.CloseContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CloseContext err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("CloseContext took %v, want it bounded by ctx", d)
+	}
+
+	bw.release()
+	want := "1\n2\n3\n"
+	if err := <-written; errors.Is(err, ec.ErrWriterClosed) {
+		want = "1\n2\n"
+	} else if err != nil {
+		t.Errorf("blocked Write err = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close after release err = %v", err)
+	}
+	if got, closed := bw.state(); got != want || !closed {
+		t.Errorf("written %q, closed = %v, want %q and closed", got, closed, want)
+	}
+}
+
+func TestRollWriterWriteAfterClose(t *testing.T) {
+	w, err := NewRollWriter(filepath.Join(t.TempDir(), "a.log"))
+	if err != nil {
+		t.Fatalf("NewRollWriter: %v", err)
+	}
+	if _, err := w.Write([]byte("line\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte("late\n")); !errors.Is(err, ec.ErrWriterClosed) {
+		t.Errorf("Write after Close err = %v, want %v", err, ec.ErrWriterClosed)
+	}
+	data, err := os.ReadFile(w.currPath)
+	if err != nil || string(data) != "line\n" {
+		t.Errorf("file = %q, %v, want the line written before closing", data, err)
+	}
+}
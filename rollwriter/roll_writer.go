@@ -44,6 +44,11 @@ type RollWriter struct {
 	notifyCh   chan bool
 	closeOnce  sync.Once
 	closeCh    chan *closeAndRenameFile
+	// closed is set by Close, it's checked with mu held before opening or
+	// renaming files.
+	closed    atomic.Bool
+	cleanWG   sync.WaitGroup
+	closingWG sync.WaitGroup
 
 	os customizedOS
 }
@@ -87,10 +92,18 @@ func NewRollWriter(filePath string, opt ...Option) (*RollWriter, error) {
 }
 
 // Write writes logs. It implements io.Writer.
+// ErrWriterClosed is returned after the writer is closed.
 func (w *RollWriter) Write(v []byte) (n int, err error) {
+	if w.closed.Load() {
+		return 0, ec.ErrWriterClosed
+	}
 	// Reopen file every 10 seconds.
 	if w.getCurrFile() == nil || time.Now().Unix()-atomic.LoadInt64(&w.openTime) > 10 {
 		w.mu.Lock()
+		if w.closed.Load() {
+			w.mu.Unlock()
+			return 0, ec.ErrWriterClosed
+		}
 		w.reopenFile()
 		w.mu.Unlock()
 	}
@@ -107,30 +120,41 @@ func (w *RollWriter) Write(v []byte) (n int, err error) {
 	// Rolling on full.
 	if w.opts.MaxSize > 0 && atomic.LoadInt64(&w.currSize) >= w.opts.MaxSize {
 		w.mu.Lock()
-		w.backupFile()
+		if !w.closed.Load() {
+			w.backupFile()
+		}
 		w.mu.Unlock()
 	}
 	return n, err
 }
 
-// Close closes the current log file. It implements io.Closer.
+// Close closes the current log file, and waits for the goroutines closing
+// rolled files and scavenging old files to finish. It implements io.Closer.
+// Closing a closed writer does nothing.
 func (w *RollWriter) Close() error {
-	if w.getCurrFile() == nil {
+	if w.closed.Swap(true) {
 		return nil
 	}
-	err := w.getCurrFile().Close()
-	w.setCurrFile(nil)
-
-	if w.notifyCh != nil {
-		close(w.notifyCh)
-		w.notifyCh = nil
+	w.mu.Lock()
+	var err error
+	if f := w.getCurrFile(); f != nil {
+		err = f.Close()
+		w.setCurrFile(nil)
 	}
-
 	if w.closeCh != nil {
 		close(w.closeCh)
-		w.closeCh = nil
 	}
+	w.mu.Unlock()
 
+	// Rolled files notify the scavenger when they are closed, so notifyCh
+	// is closed after all of them.
+	w.closingWG.Wait()
+	w.mu.Lock()
+	if w.notifyCh != nil {
+		close(w.notifyCh)
+	}
+	w.mu.Unlock()
+	w.cleanWG.Wait()
 	return err
 }
 
@@ -167,6 +191,7 @@ func (w *RollWriter) reopenFile() {
 func (w *RollWriter) notify() {
 	w.notifyOnce.Do(func() {
 		w.notifyCh = make(chan bool, 1)
+		w.cleanWG.Add(1)
 		go w.runCleanFiles()
 	})
 	select {
@@ -177,6 +202,7 @@ func (w *RollWriter) notify() {
 
 // runCleanFiles cleans redundant or expired (compressed) logs in a new goroutine.
 func (w *RollWriter) runCleanFiles() {
+	defer w.cleanWG.Done()
 	for range w.notifyCh {
 		if w.opts.MaxBackups == 0 && w.opts.MaxAge == 0 && !w.opts.Compress {
 			continue
@@ -189,6 +215,7 @@ func (w *RollWriter) runCleanFiles() {
 func (w *RollWriter) delayCloseAndRenameFile(f *closeAndRenameFile) {
 	w.closeOnce.Do(func() {
 		w.closeCh = make(chan *closeAndRenameFile, 100)
+		w.closingWG.Add(1)
 		go w.runCloseFiles()
 	})
 	w.closeCh <- f
@@ -196,6 +223,7 @@ func (w *RollWriter) delayCloseAndRenameFile(f *closeAndRenameFile) {
 
 // runCloseFiles delays closing file in a new goroutine.
 func (w *RollWriter) runCloseFiles() {
+	defer w.closingWG.Done()
 	for f := range w.closeCh {
 		time.Sleep(20 * time.Millisecond)
 		if err := f.file.Close(); err != nil {
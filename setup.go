@@ -0,0 +1,165 @@
+package zeus_log
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/oyogames2023/zeus-log/plugin"
+	yaml "gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// fileConfig is the layout of log config documents. Each key under "log" is
+// the name of a logger, and its value is the Config of the logger:
+//
+//	log:
+//	  default:
+//	    - writer: console
+//	      level: debug
+//	  access:
+//	    - writer: file
+//	      writer_config:
+//	        file_name: access.log
+//	        max_size: 100MB
+//	        max_age: 7d
+type fileConfig struct {
+	Log yaml.Node `yaml:"log"`
+}
+
+// SetupFromFile reads the log config document at path and sets up the loggers
+// in it, see SetupFromBytes.
+func SetupFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("log: read config file: %w", err)
+	}
+	return SetupFromBytes(data)
+}
+
+// SetupFromBytes parses a log config document and sets up every logger in it,
+// in the order they appear. Overrides from environment variables are applied
+// to the config first, see ApplyEnvOverrides. Each logger is built by the factory registered by
+// plugin.Register under its name, or by DefaultFactory, which registers it.
+// Failed loggers don't stop the others, all errors are returned together.
+func SetupFromBytes(data []byte) error {
+	fc, err := parseFileConfig(data)
+	if err != nil {
+		return err
+	}
+	var errs error
+	for i := 0; i+1 < len(fc.Log.Content); i += 2 {
+		name, node := fc.Log.Content[i].Value, fc.Log.Content[i+1]
+		node, overrides, err := envOverriddenNode(name, node)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("log: setup logger %q: %w", name, err))
+		}
+		if err := setupLogger(name, plugin.NewYAMLNodeDecoder(node)); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("log: setup logger %q: %w", name, err))
+			continue
+		}
+		RecordEnvOverrides(name, overrides)
+	}
+	return errs
+}
+
+// parseFileConfig parses a log config document.
+func parseFileConfig(data []byte) (*fileConfig, error) {
+	var fc fileConfig
+	if err := yaml.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("log: parse config: %w", err)
+	}
+	if fc.Log.Kind == 0 {
+		return nil, errors.New("log: parse config: no log section")
+	}
+	if fc.Log.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("log: parse config: line %d: log section should be a map of logger names to configs",
+			fc.Log.Line)
+	}
+	return &fc, nil
+}
+
+// envOverriddenNode applies the overrides from environment variables to the
+// config node of the logger `name`. Only the overridden keys are set, in a copy
+// of the node, so the rest of the config is decoded as written. The node is
+// returned as is if nothing is overridden or it can not be decoded, which is
+// left to the factory to report.
+func envOverriddenNode(name string, node *yaml.Node) (*yaml.Node, []EnvOverride, error) {
+	var cfg Config
+	if err := node.Decode(&cfg); err != nil {
+		return node, nil, nil
+	}
+	patches, err := envPatches(name, cfg)
+	if len(patches) == 0 {
+		return node, nil, err
+	}
+	out := copyNode(node)
+	overrides := make([]EnvOverride, 0, len(patches))
+	for _, p := range patches {
+		tag := "!!str"
+		if p.field == "enable_color" {
+			tag = "!!bool"
+		}
+		setNodeValue(out.Content[p.index], strings.Split(p.field, "."), tag, p.New)
+		overrides = append(overrides, p.EnvOverride)
+	}
+	return out, overrides, err
+}
+
+// copyNode returns a deep copy of n, with aliases replaced by copies of the
+// nodes they refer to, so that setting values in the copy changes nothing else.
+func copyNode(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		return copyNode(n.Alias)
+	}
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// setNodeValue sets the scalar value at the key path in the mapping node m,
+// adding the missing keys.
+func setNodeValue(m *yaml.Node, path []string, tag, value string) {
+	if m.Kind != yaml.MappingNode {
+		*m = yaml.Node{Kind: yaml.MappingNode, Line: m.Line, Column: m.Column}
+	}
+	var v *yaml.Node
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == path[0] {
+			v = m.Content[i+1]
+		}
+	}
+	if v == nil {
+		v = &yaml.Node{Line: m.Line, Column: m.Column}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}, v)
+	}
+	if len(path) > 1 {
+		setNodeValue(v, path[1:], tag, value)
+		return
+	}
+	*v = yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: v.Line, Column: v.Column}
+}
+
+// setupLogger sets up the logger `name` by its factory. A logger other than the
+// default one already registered by the name is an error, which is checked
+// before the factory builds the logger.
+func setupLogger(name string, dec plugin.Decoder) error {
+	if isRegistered(name) {
+		return fmt.Errorf("logger %s already registered", name)
+	}
+	factory := plugin.Get(pluginType, name)
+	if factory == nil {
+		factory = DefaultFactory
+	}
+	if factory == nil {
+		return errors.New("no log factory registered, import a log backend like log/zap")
+	}
+	if err := factory.Setup(name, dec); err != nil {
+		return err
+	}
+	recordSetup(factory)
+	return nil
+}
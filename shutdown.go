@@ -0,0 +1,108 @@
+package zeus_log
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/oyogames2023/zeus-log/plugin"
+	"sort"
+	"sync"
+)
+
+var (
+	setupMu sync.Mutex
+	// setupFactories are the factories which have set up loggers, in the order
+	// of their last setups.
+	setupFactories []plugin.Factory
+)
+
+// recordSetup records that factory has set up a logger.
+func recordSetup(factory plugin.Factory) {
+	setupMu.Lock()
+	defer setupMu.Unlock()
+	for i, f := range setupFactories {
+		if f == factory {
+			setupFactories = append(setupFactories[:i], setupFactories[i+1:]...)
+			break
+		}
+	}
+	setupFactories = append(setupFactories, factory)
+}
+
+// Shutdown flushes and closes all loggers before exiting, so that the tail of
+// logs is not lost. First the factories which have set up loggers are flushed
+// and closed in the reverse order of their setups, by the optional
+// plugin.Flusher and plugin.Closer interfaces, then every registered logger is
+// closed once, by ContextCloser if it implements it or else by Logger.Close.
+// Loggers already closed by their factories are expected to do nothing on the
+// second close. It returns when ctx is done, and the error reports what could
+// not be flushed or closed in time. All errors are returned together.
+func Shutdown(ctx context.Context) error {
+	setupMu.Lock()
+	factories := setupFactories
+	setupFactories = nil
+	setupMu.Unlock()
+
+	var errs error
+	for i := len(factories) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("log: shutdown: %d factories skipped: %w", i+1, err))
+			break
+		}
+		f := factories[i]
+		if flusher, ok := f.(plugin.Flusher); ok {
+			if err := flusher.Flush(); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("log: flush %s factory %T: %w", f.Type(), f, err))
+			}
+		}
+		if closer, ok := f.(plugin.Closer); ok {
+			if err := closer.Close(ctx); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("log: close %s factory %T: %w", f.Type(), f, err))
+			}
+		}
+	}
+
+	mu.RLock()
+	names := make([]string, 0, len(loggers))
+	for name := range loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	all := make([]Logger, len(names))
+	for i, name := range names {
+		all[i] = loggers[name]
+	}
+	mu.RUnlock()
+
+	// Loggers are closed concurrently, so a slow one does not stop others.
+	results := make(chan error, len(all))
+	for i := range all {
+		go func(name string, l Logger) {
+			if err := closeLogger(ctx, l); err != nil {
+				results <- fmt.Errorf("log: close logger %q: %w", name, err)
+				return
+			}
+			results <- nil
+		}(names[i], all[i])
+	}
+	for pending := len(all); pending > 0; pending-- {
+		select {
+		case err := <-results:
+			if err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		case <-ctx.Done():
+			return multierror.Append(errs,
+				fmt.Errorf("log: shutdown: %d loggers not closed: %w", pending, ctx.Err()))
+		}
+	}
+	return errs
+}
+
+// closeLogger closes l within ctx if it's a ContextCloser.
+func closeLogger(ctx context.Context, l Logger) error {
+	if cc, ok := l.(ContextCloser); ok {
+		return cc.CloseContext(ctx)
+	}
+	return l.Close()
+}
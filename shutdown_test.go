@@ -0,0 +1,165 @@
+package zeus_log
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oyogames2023/zeus-log/plugin"
+)
+
+// events records the flushes and closes of Shutdown in order.
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.list...)
+}
+
+// flushCloser is a log factory recording its flushes and closes.
+type flushCloser struct {
+	name   string
+	events *events
+}
+
+func (f *flushCloser) Type() string {
+	return pluginType
+}
+
+func (f *flushCloser) Setup(string, plugin.Decoder) error {
+	return nil
+}
+
+func (f *flushCloser) Flush() error {
+	f.events.add("flush " + f.name)
+	return nil
+}
+
+func (f *flushCloser) Close(context.Context) error {
+	f.events.add("close " + f.name)
+	return nil
+}
+
+// closingLogger is a Logger which closes by close.
+type closingLogger struct {
+	Logger
+	close func() error
+}
+
+func (l *closingLogger) Named(string) Logger {
+	return l
+}
+
+func (l *closingLogger) Close() error {
+	return l.close()
+}
+
+// contextClosingLogger is a Logger which closes within a context by close.
+type contextClosingLogger struct {
+	closingLogger
+	closeContext func(ctx context.Context) error
+}
+
+func (l *contextClosingLogger) Named(string) Logger {
+	return l
+}
+
+func (l *contextClosingLogger) CloseContext(ctx context.Context) error {
+	return l.closeContext(ctx)
+}
+
+// registerTestLogger registers l until the test ends.
+func registerTestLogger(t *testing.T, name string, l Logger) {
+	if err := RegisterE(name, l); err != nil {
+		t.Fatalf("RegisterE: %v", err)
+	}
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(loggers, name)
+	})
+}
+
+// resetSetupFactories forgets the factories recorded by a test.
+func resetSetupFactories(t *testing.T) {
+	t.Cleanup(func() {
+		setupMu.Lock()
+		defer setupMu.Unlock()
+		setupFactories = nil
+	})
+}
+
+func TestShutdownFlushesBeforeClose(t *testing.T) {
+	resetSetupFactories(t)
+	ev := &events{}
+	first, second := &flushCloser{name: "first", events: ev}, &flushCloser{name: "second", events: ev}
+	recordSetup(first)
+	recordSetup(second)
+	// Setting up again moves the factory to the end.
+	recordSetup(first)
+	registerTestLogger(t, "shutdown-plain", &closingLogger{close: func() error {
+		ev.add("close plain logger")
+		return nil
+	}})
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	want := []string{"flush first", "close first", "flush second", "close second", "close plain logger"}
+	if got := ev.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	// The factories are forgotten, only the registered loggers are closed again.
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+	if got := ev.get(); !reflect.DeepEqual(got, append(want, "close plain logger")) {
+		t.Errorf("events after the second Shutdown = %q", got)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	resetSetupFactories(t)
+	release := make(chan struct{})
+	defer close(release)
+	ctxs := make(chan context.Context, 1)
+	registerTestLogger(t, "shutdown-context", &contextClosingLogger{
+		closeContext: func(ctx context.Context) error {
+			ctxs <- ctx
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	registerTestLogger(t, "shutdown-stuck", &closingLogger{close: func() error {
+		<-release
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := Shutdown(ctx)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Shutdown took %v, want it bounded by ctx", d)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := <-ctxs; got != ctx {
+		t.Error("CloseContext not called with the ctx of Shutdown")
+	}
+}
@@ -0,0 +1,725 @@
+package zeus_log
+
+import (
+	"context"
+	"fmt"
+	ec "github.com/oyogames2023/zeus-log/errorcode"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// slogBadKey is the key used for arguments of With which can not be paired,
+// the same as the one used by log/slog.
+const slogBadKey = "!BADKEY"
+
+// Mapping between Level and slog.Level. Levels not known by slog are placed
+// around the standard ones, 4 apart like slog does.
+const (
+	slogLevelTrace = slog.LevelDebug - 4
+	slogLevelFatal = slog.LevelError + 4
+	slogLevelPanic = slog.LevelError + 8
+)
+
+// LevelFromSlog converts slog.Level to Level. Levels between the standard ones
+// are rounded down.
+func LevelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+// LevelToSlog converts Level to slog.Level.
+func LevelToSlog(level Level) slog.Level {
+	switch level {
+	case LevelTrace:
+		return slogLevelTrace
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return slogLevelFatal
+	default:
+		return slogLevelPanic
+	}
+}
+
+// slogHandler is a slog.Handler which writes records to a Logger.
+type slogHandler struct {
+	logger Logger
+	// prefix is the prefix of attribute keys made by the open groups, like "req.".
+	prefix string
+}
+
+// NewSlogHandler creates a slog.Handler which writes records to logger, so that
+// a *slog.Logger can write to the outputs of logger. Levels are converted by
+// LevelFromSlog, and groups are flattened into attribute keys separated by
+// periods, like "req.id".
+//
+// The time and caller of records are used if logger is a RecordLogger. For
+// other loggers, it's expected to be a registered one, like GetDefaultLogger or
+// Get returns, and the caller of slog.Logger methods is reported as the log
+// caller.
+func NewSlogHandler(logger Logger) slog.Handler {
+	if _, ok := logger.(RecordLogger); ok {
+		return &slogHandler{logger: logger}
+	}
+	if ol, ok := logger.(OptionLogger); ok {
+		// slog.Logger.Info -> slog.Logger.log -> slogHandler.Handle.
+		logger = ol.WithOptions(WithAdditionalCallerSkip(2))
+	}
+	return &slogHandler{logger: logger}
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if le, ok := h.logger.(LevelEnabler); ok {
+		return le.Enabled(LevelFromSlog(level))
+	}
+	return true
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, a)
+		return true
+	})
+	if rl, ok := h.logger.(RecordLogger); ok {
+		rl.LogRecord(r.Time, r.PC, LevelFromSlog(r.Level), r.Message, fields...)
+		return nil
+	}
+	h.logger.Log(LevelFromSlog(r.Level), r.Message, fields...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{logger: h.logger.WithFields(fields...), prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// appendSlogAttr converts a to fields and appends them to fields.
+func appendSlogAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	key := prefix + a.Key
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key != "" {
+			prefix = key + "."
+		}
+		for _, ga := range attrs {
+			fields = appendSlogAttr(fields, prefix, ga)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, String(key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, Uint64(key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, Float64(key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, Bool(key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, Duration(key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(key, a.Value.Time()))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, NamedErr(key, err))
+		}
+		return append(fields, Any(key, a.Value.Any()))
+	}
+}
+
+// slogLogger is a Logger implementation which writes to a slog.Handler.
+type slogLogger struct {
+	handler slog.Handler
+	// level is the minimum level of logs, shared by derived loggers.
+	level *atomic.Int32
+	name  string
+	skip  int
+}
+
+// NewSlogLogger creates a Logger which writes to handler. Like the loggers
+// created by the registered factories, the caller of the function calling its
+// methods is reported, and With, WithFields or FromContext should be used to
+// get a logger for direct use.
+//
+// Fatal logs exit by calling Terminate(1), Panic logs panic after the record
+// is handled. The name of the logger is added to records under the key "logger".
+func NewSlogLogger(handler slog.Handler) Logger {
+	level := &atomic.Int32{}
+	level.Store(int32(LevelTrace))
+	return &slogLogger{handler: handler, level: level, skip: 1}
+}
+
+// Enabled reports whether logs at level are printed.
+func (l *slogLogger) Enabled(level Level) bool {
+	minLevel := Level(l.level.Load())
+	if level == LevelOff || minLevel == LevelOff || level < minLevel {
+		return false
+	}
+	if level == LevelTrace && !IsTraceEnabled(l.name) {
+		return false
+	}
+	return l.handler.Enabled(context.Background(), LevelToSlog(level))
+}
+
+// log sends a record to the handler. It must be called by Logger methods
+// directly to report the right caller.
+func (l *slogLogger) log(level Level, msg string, fields []Field) {
+	var pcs [1]uintptr
+	// runtime.Callers -> log -> Logger method -> caller.
+	runtime.Callers(3+l.skip, pcs[:])
+	l.handle(time.Now(), pcs[0], level, msg, fields)
+}
+
+// LogRecord writes a log with the time and caller given, see RecordLogger.
+func (l *slogLogger) LogRecord(t time.Time, pc uintptr, level Level, msg string, fields ...Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	l.handle(t, pc, level, msg, fields)
+}
+
+// handle sends a record to the handler, and exits or panics for Fatal or
+// Panic logs.
+func (l *slogLogger) handle(t time.Time, pc uintptr, level Level, msg string, fields []Field) {
+	r := slog.NewRecord(t, LevelToSlog(level), msg, pc)
+	if l.name != "" {
+		r.AddAttrs(slog.String("logger", l.name))
+	}
+	for _, f := range fields {
+		r.AddAttrs(fieldToSlogAttr(f))
+	}
+	if level == LevelFatal && FatalStackDumpEnabled() {
+		r.AddAttrs(slog.String(GoroutinesKey, string(GoroutineDump())))
+	}
+	_ = l.handler.Handle(context.Background(), r)
+	switch level {
+	case LevelFatal:
+		Terminate(1)
+	case LevelPanic:
+		panic(msg)
+	}
+}
+
+// WithOptions returns a new logger with opts applied.
+func (l *slogLogger) WithOptions(opts ...Option) Logger {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c := *l
+	c.skip += o.Skip
+	return &c
+}
+
+// Trace logs to TRACE log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Trace(args ...any) {
+	if l.Enabled(LevelTrace) {
+		l.log(LevelTrace, fmt.Sprint(args...), nil)
+	}
+}
+
+// Tracef logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Tracef(format string, args ...any) {
+	if l.Enabled(LevelTrace) {
+		l.log(LevelTrace, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Traceln logs to TRACE log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Traceln(args ...any) {
+	if l.Enabled(LevelTrace) {
+		l.log(LevelTrace, fmt.Sprintln(args...), nil)
+	}
+}
+
+// Debug logs to DEBUG log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Debug(args ...any) {
+	if l.Enabled(LevelDebug) {
+		l.log(LevelDebug, fmt.Sprint(args...), nil)
+	}
+}
+
+// Debugf logs to DEBUG log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Debugf(format string, args ...any) {
+	if l.Enabled(LevelDebug) {
+		l.log(LevelDebug, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Debugln logs to DEBUG log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Debugln(args ...any) {
+	if l.Enabled(LevelDebug) {
+		l.log(LevelDebug, fmt.Sprintln(args...), nil)
+	}
+}
+
+// Info logs to INFO log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Info(args ...any) {
+	if l.Enabled(LevelInfo) {
+		l.log(LevelInfo, fmt.Sprint(args...), nil)
+	}
+}
+
+// Infof logs to INFO log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Infof(format string, args ...any) {
+	if l.Enabled(LevelInfo) {
+		l.log(LevelInfo, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Infoln logs to INFO log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Infoln(args ...any) {
+	if l.Enabled(LevelInfo) {
+		l.log(LevelInfo, fmt.Sprintln(args...), nil)
+	}
+}
+
+// Warn logs to WARNING log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Warn(args ...any) {
+	if l.Enabled(LevelWarn) {
+		l.log(LevelWarn, fmt.Sprint(args...), nil)
+	}
+}
+
+// Warnf logs to WARNING log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Warnf(format string, args ...any) {
+	if l.Enabled(LevelWarn) {
+		l.log(LevelWarn, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Warnln logs to WARNING log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Warnln(args ...any) {
+	if l.Enabled(LevelWarn) {
+		l.log(LevelWarn, fmt.Sprintln(args...), nil)
+	}
+}
+
+// Error logs to ERROR log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Error(args ...any) {
+	if l.Enabled(LevelError) {
+		l.log(LevelError, fmt.Sprint(args...), nil)
+	}
+}
+
+// Errorf logs to ERROR log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Errorf(format string, args ...any) {
+	if l.Enabled(LevelError) {
+		l.log(LevelError, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Errorln logs to ERROR log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Errorln(args ...any) {
+	if l.Enabled(LevelError) {
+		l.log(LevelError, fmt.Sprintln(args...), nil)
+	}
+}
+
+// Fatal logs to FATAL log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Fatal(args ...any) {
+	l.log(LevelFatal, fmt.Sprint(args...), nil)
+}
+
+// Fatalf logs to FATAL log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Fatalf(format string, args ...any) {
+	l.log(LevelFatal, fmt.Sprintf(format, args...), nil)
+}
+
+// Fatalln logs to FATAL log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Fatalln(args ...any) {
+	l.log(LevelFatal, fmt.Sprintln(args...), nil)
+}
+
+// Panic logs to PANIC log. Arguments are handled in the manner of fmt.Print.
+func (l *slogLogger) Panic(args ...any) {
+	l.log(LevelPanic, fmt.Sprint(args...), nil)
+}
+
+// Panicf logs to PANIC log. Arguments are handled in the manner of fmt.Printf.
+func (l *slogLogger) Panicf(format string, args ...any) {
+	l.log(LevelPanic, fmt.Sprintf(format, args...), nil)
+}
+
+// Panicln logs to PANIC log. Arguments are handled in the manner of fmt.Println.
+func (l *slogLogger) Panicln(args ...any) {
+	l.log(LevelPanic, fmt.Sprintln(args...), nil)
+}
+
+// Log logs msg with fields at level.
+func (l *slogLogger) Log(level Level, msg string, fields ...Field) {
+	if level >= LevelFatal || l.Enabled(level) {
+		l.log(level, msg, fields)
+	}
+}
+
+// TraceFields logs msg with fields to TRACE log.
+func (l *slogLogger) TraceFields(msg string, fields ...Field) {
+	if l.Enabled(LevelTrace) {
+		l.log(LevelTrace, msg, fields)
+	}
+}
+
+// DebugFields logs msg with fields to DEBUG log.
+func (l *slogLogger) DebugFields(msg string, fields ...Field) {
+	if l.Enabled(LevelDebug) {
+		l.log(LevelDebug, msg, fields)
+	}
+}
+
+// InfoFields logs msg with fields to INFO log.
+func (l *slogLogger) InfoFields(msg string, fields ...Field) {
+	if l.Enabled(LevelInfo) {
+		l.log(LevelInfo, msg, fields)
+	}
+}
+
+// WarnFields logs msg with fields to WARNING log.
+func (l *slogLogger) WarnFields(msg string, fields ...Field) {
+	if l.Enabled(LevelWarn) {
+		l.log(LevelWarn, msg, fields)
+	}
+}
+
+// ErrorFields logs msg with fields to ERROR log.
+func (l *slogLogger) ErrorFields(msg string, fields ...Field) {
+	if l.Enabled(LevelError) {
+		l.log(LevelError, msg, fields)
+	}
+}
+
+// FatalFields logs msg with fields to FATAL log.
+func (l *slogLogger) FatalFields(msg string, fields ...Field) {
+	l.log(LevelFatal, msg, fields)
+}
+
+// PanicFields logs msg with fields to PANIC log.
+func (l *slogLogger) PanicFields(msg string, fields ...Field) {
+	l.log(LevelPanic, msg, fields)
+}
+
+// Sync does nothing, slog.Handler has no notion of flushing.
+func (l *slogLogger) Sync() error {
+	return nil
+}
+
+// Close does nothing, the handler is owned by the caller.
+func (l *slogLogger) Close() error {
+	return nil
+}
+
+// slogOutput is the writer type of the only output of slogLogger.
+const slogOutput = "slog"
+
+// SetLevel sets the minimum level of logs passed to the handler. There is only
+// one output, addressed by "", AllOutputs, "slog" or "0".
+func (l *slogLogger) SetLevel(output string, level Level) error {
+	if err := checkSlogOutput(output); err != nil {
+		return err
+	}
+	if _, ok := LevelStrings[level]; !ok {
+		return fmt.Errorf("log: set level of output %q: invalid level %d", output, level)
+	}
+	l.level.Store(int32(level))
+	return nil
+}
+
+// GetLevel gets the minimum level of logs passed to the handler.
+func (l *slogLogger) GetLevel(output string) (Level, error) {
+	if err := checkSlogOutput(output); err != nil {
+		return LevelOff, err
+	}
+	return Level(l.level.Load()), nil
+}
+
+// OutputLevels lists the only output with its current level.
+func (l *slogLogger) OutputLevels() []OutputLevel {
+	return []OutputLevel{{Writer: slogOutput, Level: Level(l.level.Load())}}
+}
+
+func checkSlogOutput(output string) error {
+	switch output {
+	case "", AllOutputs, slogOutput, "0":
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ec.ErrUnknownOutput, output)
+	}
+}
+
+// With returns a new logger with key/value pairs. Arguments are paired in the
+// manner of slog.Logger.With, and a Field argument is used as a complete field.
+func (l *slogLogger) With(args ...any) Logger {
+	var attrs []slog.Attr
+	for i := 0; i < len(args); {
+		switch arg := args[i].(type) {
+		case Field:
+			attrs = append(attrs, fieldToSlogAttr(arg))
+			i++
+		case slog.Attr:
+			attrs = append(attrs, arg)
+			i++
+		case string:
+			if i+1 == len(args) {
+				attrs = append(attrs, slog.String(slogBadKey, arg))
+				i++
+				continue
+			}
+			attrs = append(attrs, slog.Any(arg, args[i+1]))
+			i += 2
+		default:
+			attrs = append(attrs, slog.Any(slogBadKey, arg))
+			i++
+		}
+	}
+	return l.withAttrs(attrs)
+}
+
+// WithFields returns a new logger with `fields` set.
+func (l *slogLogger) WithFields(fields ...Field) Logger {
+	attrs := make([]slog.Attr, len(fields))
+	for i := range fields {
+		attrs[i] = fieldToSlogAttr(fields[i])
+	}
+	return l.withAttrs(attrs)
+}
+
+func (l *slogLogger) withAttrs(attrs []slog.Attr) Logger {
+	c := *l
+	c.handler = l.handler.WithAttrs(attrs)
+	return &c
+}
+
+// Named returns a new logger with name appended to the name of l, separated by
+// a period.
+func (l *slogLogger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	c := *l
+	if l.name == "" {
+		c.name = name
+	} else {
+		c.name = l.name + "." + name
+	}
+	return &c
+}
+
+// fieldToSlogAttr converts a user defined field to slog.Attr.
+func fieldToSlogAttr(f Field) slog.Attr {
+	switch f.Type {
+	case StringType:
+		return slog.String(f.Key, f.String)
+	case Int64Type:
+		return slog.Int64(f.Key, f.Integer)
+	case Uint64Type:
+		return slog.Uint64(f.Key, uint64(f.Integer))
+	case Float64Type, BoolType, DurationType, TimeType, TimeFullType:
+		return slog.Any(f.Key, f.Interface())
+	case StringerType:
+		if s, ok := f.Value.(fmt.Stringer); ok {
+			return slog.Any(f.Key, stringerValuer{s})
+		}
+	case ObjectType:
+		if m, ok := f.Value.(ObjectMarshaler); ok {
+			return slog.Any(f.Key, objectValuer{m})
+		}
+	case ArrayType:
+		if m, ok := f.Value.(ArrayMarshaler); ok {
+			return slog.Any(f.Key, arrayValuer{m})
+		}
+	}
+	return slog.Any(f.Key, f.Value)
+}
+
+// stringerValuer calls String lazily when the record is handled.
+type stringerValuer struct {
+	s fmt.Stringer
+}
+
+// LogValue implements slog.LogValuer.
+func (v stringerValuer) LogValue() slog.Value {
+	return slog.StringValue(v.s.String())
+}
+
+// objectValuer marshals an ObjectMarshaler to a slog group lazily.
+type objectValuer struct {
+	m ObjectMarshaler
+}
+
+// LogValue implements slog.LogValuer.
+func (v objectValuer) LogValue() slog.Value {
+	enc := &slogObjectEncoder{}
+	if err := v.m.MarshalLogObject(enc); err != nil {
+		enc.attrs = append(enc.attrs, slog.String("error", err.Error()))
+	}
+	return slog.GroupValue(enc.attrs...)
+}
+
+// arrayValuer marshals an ArrayMarshaler to a slice lazily.
+type arrayValuer struct {
+	m ArrayMarshaler
+}
+
+// LogValue implements slog.LogValuer.
+func (v arrayValuer) LogValue() slog.Value {
+	enc := &slogArrayEncoder{}
+	if err := v.m.MarshalLogArray(enc); err != nil {
+		enc.values = append(enc.values, err.Error())
+	}
+	return slog.AnyValue(enc.values)
+}
+
+// slogObjectEncoder is an ObjectEncoder which collects slog attributes.
+type slogObjectEncoder struct {
+	attrs []slog.Attr
+}
+
+func (e *slogObjectEncoder) AddString(key, value string) {
+	e.attrs = append(e.attrs, slog.String(key, value))
+}
+
+func (e *slogObjectEncoder) AddInt64(key string, value int64) {
+	e.attrs = append(e.attrs, slog.Int64(key, value))
+}
+
+func (e *slogObjectEncoder) AddUint64(key string, value uint64) {
+	e.attrs = append(e.attrs, slog.Uint64(key, value))
+}
+
+func (e *slogObjectEncoder) AddFloat64(key string, value float64) {
+	e.attrs = append(e.attrs, slog.Float64(key, value))
+}
+
+func (e *slogObjectEncoder) AddBool(key string, value bool) {
+	e.attrs = append(e.attrs, slog.Bool(key, value))
+}
+
+func (e *slogObjectEncoder) AddDuration(key string, value time.Duration) {
+	e.attrs = append(e.attrs, slog.Duration(key, value))
+}
+
+func (e *slogObjectEncoder) AddTime(key string, value time.Time) {
+	e.attrs = append(e.attrs, slog.Time(key, value))
+}
+
+func (e *slogObjectEncoder) AddBinary(key string, value []byte) {
+	e.attrs = append(e.attrs, slog.Any(key, value))
+}
+
+func (e *slogObjectEncoder) AddObject(key string, marshaler ObjectMarshaler) error {
+	e.attrs = append(e.attrs, slog.Any(key, objectValuer{marshaler}))
+	return nil
+}
+
+func (e *slogObjectEncoder) AddArray(key string, marshaler ArrayMarshaler) error {
+	e.attrs = append(e.attrs, slog.Any(key, arrayValuer{marshaler}))
+	return nil
+}
+
+func (e *slogObjectEncoder) AddReflected(key string, value any) error {
+	e.attrs = append(e.attrs, slog.Any(key, value))
+	return nil
+}
+
+// slogArrayEncoder is an ArrayEncoder which collects plain values. Objects in
+// arrays are collected as maps, since slog has no group element of slices.
+type slogArrayEncoder struct {
+	values []any
+}
+
+func (e *slogArrayEncoder) AppendString(value string)          { e.values = append(e.values, value) }
+func (e *slogArrayEncoder) AppendInt64(value int64)            { e.values = append(e.values, value) }
+func (e *slogArrayEncoder) AppendUint64(value uint64)          { e.values = append(e.values, value) }
+func (e *slogArrayEncoder) AppendFloat64(value float64)        { e.values = append(e.values, value) }
+func (e *slogArrayEncoder) AppendBool(value bool)              { e.values = append(e.values, value) }
+func (e *slogArrayEncoder) AppendDuration(value time.Duration) { e.values = append(e.values, value) }
+func (e *slogArrayEncoder) AppendTime(value time.Time)         { e.values = append(e.values, value) }
+
+func (e *slogArrayEncoder) AppendObject(marshaler ObjectMarshaler) error {
+	enc := &slogObjectEncoder{}
+	err := marshaler.MarshalLogObject(enc)
+	e.values = append(e.values, slogAttrsToMap(enc.attrs))
+	return err
+}
+
+func (e *slogArrayEncoder) AppendArray(marshaler ArrayMarshaler) error {
+	enc := &slogArrayEncoder{}
+	err := marshaler.MarshalLogArray(enc)
+	e.values = append(e.values, enc.values)
+	return err
+}
+
+func (e *slogArrayEncoder) AppendReflected(value any) error {
+	e.values = append(e.values, value)
+	return nil
+}
+
+// slogAttrsToMap converts attributes to a map, resolving nested values.
+func slogAttrsToMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			m[a.Key] = slogAttrsToMap(v.Group())
+			continue
+		}
+		m[a.Key] = v.Any()
+	}
+	return m
+}
+
+var (
+	_ slog.Handler  = (*slogHandler)(nil)
+	_ Logger        = (*slogLogger)(nil)
+	_ OptionLogger  = (*slogLogger)(nil)
+	_ LevelEnabler  = (*slogLogger)(nil)
+	_ ObjectEncoder = (*slogObjectEncoder)(nil)
+	_ ArrayEncoder  = (*slogArrayEncoder)(nil)
+)
@@ -0,0 +1,231 @@
+package zeus_log
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type testUser struct {
+	id   int64
+	name string
+}
+
+func (u testUser) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddInt64("id", u.id)
+	enc.AddString("name", u.name)
+	return nil
+}
+
+// secret is a slog.LogValuer which hides its value.
+type secret string
+
+func (s secret) LogValue() slog.Value {
+	return slog.StringValue("***")
+}
+
+// recordAttrs returns the attributes of r by key, with their values resolved.
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Resolve()
+		return true
+	})
+	return attrs
+}
+
+func TestSlogLevelMapping(t *testing.T) {
+	for _, level := range []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		if got := LevelFromSlog(LevelToSlog(level)); got != level {
+			t.Errorf("LevelFromSlog(LevelToSlog(%v)) = %v", level, got)
+		}
+	}
+	tests := []struct {
+		level slog.Level
+		want  Level
+	}{
+		{level: slog.LevelDebug - 8, want: LevelTrace},
+		{level: slogLevelTrace, want: LevelTrace},
+		{level: slog.LevelDebug + 1, want: LevelDebug},
+		{level: slog.LevelInfo + 2, want: LevelInfo},
+		{level: slog.LevelWarn + 3, want: LevelWarn},
+		// Levels above ERROR never exit or panic.
+		{level: slogLevelFatal, want: LevelError},
+		{level: slogLevelPanic, want: LevelError},
+	}
+	for _, tt := range tests {
+		if got := LevelFromSlog(tt.level); got != tt.want {
+			t.Errorf("LevelFromSlog(%v) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+	if LevelToSlog(LevelFatal) <= slog.LevelError || LevelToSlog(LevelPanic) <= LevelToSlog(LevelFatal) {
+		t.Errorf("LevelToSlog(FATAL) = %v, LevelToSlog(PANIC) = %v, want above ERROR in order",
+			LevelToSlog(LevelFatal), LevelToSlog(LevelPanic))
+	}
+}
+
+func TestSlogLoggerLevels(t *testing.T) {
+	resetTrace(t)
+	h := newRecordHandler()
+	l := NewSlogLogger(h)
+
+	SetTraceEnabled(false)
+	l.Trace("trace off")
+	SetTraceEnabled(true)
+	l.Trace("trace")
+	l.Debug("debug")
+	l.Error("error")
+	if got, want := strings.Join(h.messages(), ","), "trace,debug,error"; got != want {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	for i, want := range []slog.Level{slogLevelTrace, slog.LevelDebug, slog.LevelError} {
+		if got := (*h.records)[i].Level; got != want {
+			t.Errorf("record %d level = %v, want %v", i, got, want)
+		}
+	}
+
+	if err := l.SetLevel(AllOutputs, LevelWarn); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	if l.(LevelEnabler).Enabled(LevelInfo) || !l.(LevelEnabler).Enabled(LevelWarn) {
+		t.Error("Enabled does not follow the level set")
+	}
+	if err := l.SetLevel(slogOutput, LevelOff); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	named := l.Named("sub")
+	named.Error("off")
+	for _, level := range []Level{LevelOff, LevelTrace, LevelError} {
+		if named.(LevelEnabler).Enabled(level) {
+			t.Errorf("Enabled(%v) = true at level off", level)
+		}
+	}
+	if got := len(h.messages()); got != 3 {
+		t.Errorf("records = %d after logging at level off, want 3", got)
+	}
+	if level, err := l.GetLevel(""); err != nil || level != LevelOff {
+		t.Errorf("GetLevel = %v, %v, want off", level, err)
+	}
+	if err := l.SetLevel("file", LevelInfo); err == nil {
+		t.Error("SetLevel of an unknown output succeeded")
+	}
+}
+
+func TestSlogLoggerFields(t *testing.T) {
+	h := newRecordHandler()
+	direct := NewSlogLogger(h).(OptionLogger).WithOptions(WithAdditionalCallerSkip(-1))
+	l := direct.Named("room").With("room", "lobby", String("mode", "duel"), "dangling")
+	l.InfoFields("joined",
+		Int64("n", 2),
+		Uint64("u", 3),
+		Object("user", testUser{id: 7, name: "ann"}),
+		Stringer("addr", secretStringer{}),
+		Any("token", secret("t0k3n")),
+	)
+
+	r := h.last(t)
+	if fn := recordFunction(r); !strings.HasSuffix(fn, ".TestSlogLoggerFields") {
+		t.Errorf("caller = %s, want the test", fn)
+	}
+	attrs := recordAttrs(r)
+	for key, want := range map[string]string{
+		"logger":   "room",
+		"room":     "lobby",
+		"mode":     "duel",
+		slogBadKey: "dangling",
+		"n":        "2",
+		"u":        "3",
+		"addr":     "addr",
+		"token":    "***",
+		"user":     "[id=7 name=ann]",
+	} {
+		v, ok := attrs[key]
+		if !ok {
+			t.Errorf("attribute %s missing in %v", key, attrs)
+			continue
+		}
+		if got := v.String(); got != want {
+			t.Errorf("attribute %s = %q, want %q", key, got, want)
+		}
+	}
+	if k := attrs["n"].Kind(); k != slog.KindInt64 {
+		t.Errorf("attribute n kind = %v, want Int64", k)
+	}
+	if k := attrs["user"].Kind(); k != slog.KindGroup {
+		t.Errorf("attribute user kind = %v, want Group", k)
+	}
+}
+
+// secretStringer is a fmt.Stringer with a constant string.
+type secretStringer struct{}
+
+func (secretStringer) String() string {
+	return "addr"
+}
+
+func TestSlogHandlerGroupsAndAttrs(t *testing.T) {
+	h := newRecordHandler()
+	logger := slog.New(NewSlogHandler(NewSlogLogger(h)))
+
+	logger.WithGroup("req").With("id", 1, slog.Group("empty")).WithGroup("").Info("served",
+		"path", "/",
+		"token", secret("t0k3n"),
+		slog.Group("peer", "ip", "10.0.0.1", slog.Group("geo", "city", "x")),
+		slog.Group("", "inline", true),
+		slog.Attr{},
+	)
+
+	r := h.last(t)
+	if r.Message != "served" || r.Level != slog.LevelInfo {
+		t.Errorf("record = %s %q, want INFO served", r.Level, r.Message)
+	}
+	if fn := recordFunction(r); !strings.HasSuffix(fn, ".TestSlogHandlerGroupsAndAttrs") {
+		t.Errorf("caller = %s, want the test", fn)
+	}
+	want := map[string]string{
+		"req.id":            "1",
+		"req.path":          "/",
+		"req.token":         "***",
+		"req.peer.ip":       "10.0.0.1",
+		"req.peer.geo.city": "x",
+		"req.inline":        "true",
+	}
+	attrs := recordAttrs(r)
+	if len(attrs) != len(want) {
+		t.Errorf("attributes = %v, want %v", attrs, want)
+	}
+	for key, v := range want {
+		if got, ok := attrs[key]; !ok || got.String() != v {
+			t.Errorf("attribute %s = %v, want %q", key, got, v)
+		}
+	}
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	resetTrace(t)
+	SetTraceEnabled(true)
+	h := newRecordHandler()
+	l := NewSlogLogger(h)
+	logger := slog.New(NewSlogHandler(l))
+
+	logger.Log(context.Background(), slogLevelTrace, "trace")
+	logger.Log(context.Background(), slogLevelFatal, "above error")
+	if got, want := strings.Join(h.messages(), ","), "trace,above error"; got != want {
+		t.Fatalf("messages = %q, want %q", got, want)
+	}
+	if got := h.last(t).Level; got != slog.LevelError {
+		t.Errorf("level above ERROR handled as %v, want ERROR", got)
+	}
+
+	if err := l.SetLevel("", LevelOff); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	if logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("handler enabled at level off")
+	}
+	logger.Error("off")
+	if got := len(h.messages()); got != 2 {
+		t.Errorf("records = %d after logging at level off, want 2", got)
+	}
+}
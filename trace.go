@@ -0,0 +1,78 @@
+package zeus_log
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// traceEnabled is the global trace switch, initialized from environment.
+	traceEnabled atomic.Bool
+
+	// traceOverrides holds the per logger trace switches. It's replaced as a
+	// whole on change, so readers need no locking.
+	traceOverrides   atomic.Pointer[map[string]bool]
+	traceOverridesMu sync.Mutex
+)
+
+func init() {
+	traceEnabled.Store(traceEnableFromEnv())
+}
+
+// SetTraceEnabled turns trace logs on or off globally. Loggers with their own
+// switch set by SetTraceEnabledFor are not affected.
+func SetTraceEnabled(enabled bool) {
+	traceEnabled.Store(enabled)
+}
+
+// SetTraceEnabledFor turns trace logs on or off for the logger named `name` and
+// its descendants, like "matchmaking" for "matchmaking.queue". It takes
+// precedence over the global switch and the switches of ancestors.
+func SetTraceEnabledFor(name string, enabled bool) {
+	updateTraceOverrides(func(m map[string]bool) {
+		m[name] = enabled
+	})
+}
+
+// ResetTraceEnabledFor removes the trace switch of the logger named `name`, so
+// that it follows its ancestors or the global switch again.
+func ResetTraceEnabledFor(name string) {
+	updateTraceOverrides(func(m map[string]bool) {
+		delete(m, name)
+	})
+}
+
+// IsTraceEnabled reports whether trace logs are enabled for the logger named
+// `name`. The switch of the nearest named ancestor wins, the global switch is
+// used if there is none. Loggers check it in their Trace methods.
+func IsTraceEnabled(name string) bool {
+	overrides := traceOverrides.Load()
+	if overrides == nil || len(*overrides) == 0 {
+		return traceEnabled.Load()
+	}
+	for {
+		if enabled, ok := (*overrides)[name]; ok {
+			return enabled
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return traceEnabled.Load()
+}
+
+func updateTraceOverrides(update func(m map[string]bool)) {
+	traceOverridesMu.Lock()
+	defer traceOverridesMu.Unlock()
+	m := make(map[string]bool)
+	if old := traceOverrides.Load(); old != nil {
+		for k, v := range *old {
+			m[k] = v
+		}
+	}
+	update(m)
+	traceOverrides.Store(&m)
+}
@@ -0,0 +1,85 @@
+package zeus_log
+
+import "testing"
+
+// resetTrace restores the trace switches when the test ends.
+func resetTrace(t *testing.T) {
+	global := traceEnabled.Load()
+	t.Cleanup(func() {
+		SetTraceEnabled(global)
+		updateTraceOverrides(func(m map[string]bool) {
+			for k := range m {
+				delete(m, k)
+			}
+		})
+	})
+}
+
+func TestIsTraceEnabled(t *testing.T) {
+	resetTrace(t)
+	SetTraceEnabledFor("match", true)
+	SetTraceEnabledFor("match.queue", false)
+	SetTraceEnabledFor("match.queue.vip", true)
+	SetTraceEnabledFor("chat", false)
+
+	tests := []struct {
+		name   string
+		global bool
+		want   bool
+	}{
+		{name: "match", want: true},
+		{name: "match.room", want: true},
+		{name: "match.queue", global: true, want: false},
+		{name: "match.queue.normal", global: true, want: false},
+		{name: "match.queue.vip", want: true},
+		{name: "match.queue.vip.gold", want: true},
+		// Only whole name segments are ancestors.
+		{name: "matchmaking", want: false},
+		{name: "matchmaking", global: true, want: true},
+		{name: "chat.room", global: true, want: false},
+		{name: "", want: false},
+		{name: "", global: true, want: true},
+		{name: "other.sub", global: true, want: true},
+	}
+	for _, tt := range tests {
+		SetTraceEnabled(tt.global)
+		if got := IsTraceEnabled(tt.name); got != tt.want {
+			t.Errorf("IsTraceEnabled(%q) with global %v = %v, want %v", tt.name, tt.global, got, tt.want)
+		}
+	}
+}
+
+func TestResetTraceEnabledFor(t *testing.T) {
+	resetTrace(t)
+	SetTraceEnabled(false)
+	SetTraceEnabledFor("match", true)
+	SetTraceEnabledFor("match.queue", false)
+
+	ResetTraceEnabledFor("match.queue")
+	if !IsTraceEnabled("match.queue") {
+		t.Error("match.queue does not follow its ancestor after reset")
+	}
+	ResetTraceEnabledFor("match")
+	if IsTraceEnabled("match.queue") || IsTraceEnabled("match") {
+		t.Error("match does not follow the global switch after reset")
+	}
+	SetTraceEnabled(true)
+	if !IsTraceEnabled("match.queue") {
+		t.Error("match.queue does not follow the global switch after reset")
+	}
+	// Resetting a name without a switch is a no-op.
+	ResetTraceEnabledFor("unknown")
+	if !IsTraceEnabled("unknown") {
+		t.Error("unknown does not follow the global switch")
+	}
+}
+
+func TestTraceOverridesNotShared(t *testing.T) {
+	resetTrace(t)
+	SetTraceEnabledFor("match", true)
+	before := traceOverrides.Load()
+	SetTraceEnabledFor("chat", true)
+	if _, ok := (*before)["chat"]; ok {
+		t.Error("overrides read before a change are modified by it")
+	}
+}
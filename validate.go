@@ -0,0 +1,233 @@
+package zeus_log
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"sort"
+	"strings"
+)
+
+// Formatters registered by the log backends, console is the default one.
+const (
+	FormatterConsole = "console"
+	FormatterJSON    = "json"
+)
+
+// Caller formats.
+const (
+	CallerFormatShort          = "short"
+	CallerFormatFull           = "full"
+	CallerFormatModuleRelative = "module-relative"
+	CallerFormatNone           = "none"
+)
+
+// Function name formats.
+const (
+	FunctionFormatFull    = "full"
+	FunctionFormatPackage = "package"
+	FunctionFormatMethod  = "method"
+)
+
+// Stack trace formats of JSON output.
+const (
+	StacktraceFormatString = "string"
+	StacktraceFormatArray  = "array"
+)
+
+// ConfigError reports an invalid field of the log config.
+type ConfigError struct {
+	// Index is the index of the output in Config, or -1 if the error is not
+	// about a single output.
+	Index int
+	// Field is the YAML path of the field in the output, like "writer_config.roll_type".
+	Field string
+	// Value is the invalid value.
+	Value any
+	// Allowed lists the allowed values if they are enumerable.
+	Allowed []string
+	// Reason describes what is wrong with the value.
+	Reason string
+}
+
+// Error implements error.
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	b.WriteString("log: invalid config")
+	if e.Index >= 0 {
+		fmt.Fprintf(&b, ": output[%d]", e.Index)
+	}
+	if e.Field != "" {
+		if e.Index >= 0 {
+			b.WriteByte('.')
+		} else {
+			b.WriteString(": ")
+		}
+		b.WriteString(e.Field)
+	}
+	if e.Value != nil {
+		fmt.Fprintf(&b, ": %#v", e.Value)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, ": %s", e.Reason)
+	}
+	if len(e.Allowed) > 0 {
+		fmt.Fprintf(&b, ", allowed: %s", strings.Join(e.Allowed, ", "))
+	}
+	return b.String()
+}
+
+// Validate checks every output of the config, and reports all problems found
+// as *ConfigError joined by multierror.
+func (c Config) Validate() error {
+	if len(c) == 0 {
+		return &ConfigError{Index: -1, Reason: "no output configured"}
+	}
+	var errs error
+	for i := range c {
+		if err := c[i].validate(i); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Validate checks the output config, and reports all problems found as
+// *ConfigError joined by multierror. The Index of errors is -1.
+func (c *OutputConfig) Validate() error {
+	return c.validate(-1)
+}
+
+func (c *OutputConfig) validate(index int) error {
+	v := &configValidator{index: index}
+
+	switch {
+	case c.Writer == "":
+		v.fail("writer", nil, "required", writerNames()...)
+	case GetWriter(c.Writer) == nil:
+		v.fail("writer", c.Writer, "not registered", writerNames()...)
+	}
+	if c.Formatter != "" && GetFormatter(c.Formatter) == nil {
+		v.fail("formatter", c.Formatter, "not registered", formatterNames()...)
+	}
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			v.fail("level", c.Level, "unknown level", levelNames()...)
+		}
+	}
+	if c.CallerSkip < 0 {
+		v.fail("caller_skip", c.CallerSkip, "should not be negative")
+	}
+	if c.StacktraceLevel != "" {
+		if _, err := ParseLevel(c.StacktraceLevel); err != nil {
+			v.fail("stacktrace_level", c.StacktraceLevel, "unknown level", levelNames()...)
+		}
+	}
+	if c.StacktraceDepth < 0 {
+		v.fail("stacktrace_depth", c.StacktraceDepth, "should not be negative")
+	}
+	v.oneOf("format_config.caller_format", c.FormatConfig.CallerFormat, "",
+		CallerFormatShort, CallerFormatFull, CallerFormatModuleRelative, CallerFormatNone)
+	v.oneOf("format_config.function_format", c.FormatConfig.FunctionFormat, "",
+		FunctionFormatFull, FunctionFormatPackage, FunctionFormatMethod)
+	v.oneOf("format_config.stacktrace_format", c.FormatConfig.StacktraceFormat,
+		"", StacktraceFormatString, StacktraceFormatArray)
+
+	wc := &c.WriterConfig
+	if c.Writer == OutputFile && wc.FileName == "" {
+		v.fail("writer_config.file_name", nil, "required by file writer")
+	}
+	v.oneOf("writer_config.write_mode", wc.WriteMode, "", "sync", "async", "fast")
+	v.oneOf("writer_config.roll_type", wc.RollType, "", RollingBySizeStr, RollingByTimeStr)
+	v.oneOf("writer_config.time_unit", string(wc.TimeUnit), "", Minute, Hour, Day, Month, Year)
+	if wc.MaxAge < 0 {
+		v.fail("writer_config.max_age", int(wc.MaxAge), "should not be negative")
+	}
+	if wc.MaxBackups < 0 {
+		v.fail("writer_config.max_backups", wc.MaxBackups, "should not be negative")
+	}
+	if wc.MaxSize < 0 {
+		v.fail("writer_config.max_size", int(wc.MaxSize), "should not be negative")
+	}
+
+	sc := &c.Sampling
+	if sc.Tick < 0 {
+		v.fail("sampling.tick", sc.Tick.String(), "should not be negative")
+	}
+	v.samplingPolicy("sampling", sc.Policy())
+	names := make([]string, 0, len(sc.Levels))
+	for name := range sc.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if lv, err := ParseLevel(name); err != nil || lv == LevelOff {
+			v.fail("sampling.levels", name, "unknown level", levelNames()[1:]...)
+		}
+		v.samplingPolicy("sampling.levels."+name, sc.Levels[name])
+	}
+
+	tc := &c.Throttle
+	if tc.EntriesPerSecond < 0 {
+		v.fail("throttle.entries_per_second", tc.EntriesPerSecond, "should not be negative")
+	}
+	if tc.BytesPerSecond < 0 {
+		v.fail("throttle.bytes_per_second", int64(tc.BytesPerSecond), "should not be negative")
+	}
+	if tc.SummaryInterval < 0 {
+		v.fail("throttle.summary_interval", tc.SummaryInterval.String(), "should not be negative")
+	}
+	if c.Dedup.Window < 0 {
+		v.fail("dedup.window", c.Dedup.Window.String(), "should not be negative")
+	}
+	return v.errs
+}
+
+func (v *configValidator) samplingPolicy(field string, p SamplingPolicy) {
+	if p.Initial < 0 {
+		v.fail(field+".initial", p.Initial, "should not be negative")
+	}
+	if p.Thereafter < 0 {
+		v.fail(field+".thereafter", p.Thereafter, "should not be negative")
+	}
+}
+
+// configValidator collects the errors of an output config.
+type configValidator struct {
+	index int
+	errs  error
+}
+
+func (v *configValidator) fail(field string, value any, reason string, allowed ...string) {
+	v.errs = multierror.Append(v.errs, &ConfigError{
+		Index:   v.index,
+		Field:   field,
+		Value:   value,
+		Allowed: allowed,
+		Reason:  reason,
+	})
+}
+
+// oneOf checks value is one of allowed, the empty value is not listed in errors.
+func (v *configValidator) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	var listed []string
+	for _, a := range allowed {
+		if a != "" {
+			listed = append(listed, a)
+		}
+	}
+	v.fail(field, value, "unknown value", listed...)
+}
+
+// levelNames returns the level names in order.
+func levelNames() []string {
+	names := make([]string, 0, len(LevelStrings))
+	for lv := LevelOff; lv <= LevelPanic; lv++ {
+		names = append(names, LevelStrings[lv])
+	}
+	return names
+}
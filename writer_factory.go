@@ -1,7 +1,10 @@
 package zeus_log
 
 import (
+	"fmt"
 	"github.com/oyogames2023/zeus-log/plugin"
+	"sort"
+	"sync"
 )
 
 var (
@@ -10,15 +13,56 @@ var (
 	// DefaultFileWriterFactory is the default file output implementation.
 	DefaultFileWriterFactory plugin.Factory
 
-	writers = make(map[string]plugin.Factory)
+	writersMu sync.RWMutex
+	writers   = make(map[string]plugin.Factory)
 )
 
 // RegisterWriter registers log output writer. Writer may have multiple implementations.
+// It panics if a writer is already registered by the name, use RegisterWriterE
+// to get the error.
 func RegisterWriter(name string, writer plugin.Factory) {
+	if err := RegisterWriterE(name, writer); err != nil {
+		panic(err.Error())
+	}
+}
+
+// RegisterWriterE is RegisterWriter which returns an error instead of panic if
+// a writer is already registered by the name.
+func RegisterWriterE(name string, writer plugin.Factory) error {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if _, dup := writers[name]; dup {
+		return fmt.Errorf("log: writer %s registered twice", name)
+	}
 	writers[name] = writer
+	return nil
+}
+
+// UnregisterWriter removes the log output writer, and reports whether it was
+// registered.
+func UnregisterWriter(name string) bool {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	_, ok := writers[name]
+	delete(writers, name)
+	return ok
 }
 
 // GetWriter gets log output writer, returns nil if not exist.
 func GetWriter(name string) plugin.Factory {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
 	return writers[name]
 }
+
+// writerNames returns the sorted names of registered writers.
+func writerNames() []string {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	names := make([]string, 0, len(writers))
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,32 @@
+package zeus_log
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRegisterWriter(t *testing.T) {
+	const name = "test-writer-registry"
+	RegisterWriter(name, stubFormatter{})
+	t.Cleanup(func() { UnregisterWriter(name) })
+
+	if w := GetWriter(name); w != (stubFormatter{}) {
+		t.Errorf("GetWriter = %v, want the registered one", w)
+	}
+	err := RegisterWriterE(name, stubFormatter{})
+	if err == nil || !strings.Contains(err.Error(), "writer test-writer-registry registered twice") {
+		t.Errorf("RegisterWriterE err = %v, want registered twice", err)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "registered twice") {
+				t.Errorf("second RegisterWriter recovered %v, want panic of registered twice", r)
+			}
+		}()
+		RegisterWriter(name, stubFormatter{})
+	}()
+	if !contains(writerNames(), name) {
+		t.Errorf("writerNames = %q, want %s listed", writerNames(), name)
+	}
+}